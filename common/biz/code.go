@@ -20,6 +20,7 @@ var (
 	NotEnoughGold               = msError.NewError(11, errors.New("钻石不足"))
 	UserDataLocked              = msError.NewError(12, errors.New("用户数据被锁定"))
 	NotEnoughScore              = msError.NewError(13, errors.New("积分不足"))
+	DuplicateBalanceChange      = msError.NewError(14, errors.New("重复的余额变化"))
 	AccountOrPasswordError      = msError.NewError(101, errors.New("账号或密码错误"))
 	GetHallServersFail          = msError.NewError(102, errors.New("获取大厅服务器失败"))
 	AccountExist                = msError.NewError(103, errors.New("账号已存在"))
@@ -49,4 +50,12 @@ var (
 	RoomNotExist                = msError.NewError(308, errors.New("房间不存在"))
 	CanNotEnterNotLocation      = msError.NewError(309, errors.New("无法进入房间，获取定位信息失败"))
 	CanNotEnterTooNear          = msError.NewError(310, errors.New("无法进入房间，与房间中的其他玩家太近"))
+	CanNotWatch                 = msError.NewError(311, errors.New("房间不允许观战"))
+	RoomWatcherCountFull        = msError.NewError(312, errors.New("房间观战人数已满"))
+	CanNotEnterGameStarted      = msError.NewError(313, errors.New("游戏已开始，房间不允许中途加入"))
+	VoiceDisabled               = msError.NewError(314, errors.New("房间未开启语音"))
+	VoiceTooLarge               = msError.NewError(315, errors.New("语音超过大小或者时长限制"))
+	VoiceNotExist               = msError.NewError(316, errors.New("语音不存在或者已过期"))
+	AlreadyInOtherRoom          = msError.NewError(317, errors.New("已经在其他房间中"))
+	SettleFailed                = msError.NewError(318, errors.New("结算失败，房间已解散"))
 )
@@ -0,0 +1,19 @@
+package cmd
+
+import "strings"
+
+// NormalizeArgs 兼容之前flag包的单横线长参数 -config application.yml 转换为 --config application.yml
+// cobra会把-config解析为短参数组合
+func NormalizeArgs(args []string) []string {
+	res := make([]string, len(args))
+	for i, v := range args {
+		if len(v) > 2 && v[0] == '-' && v[1] != '-' {
+			name := strings.SplitN(v[1:], "=", 2)[0]
+			if len(name) > 1 {
+				v = "-" + v
+			}
+		}
+		res[i] = v
+	}
+	return res
+}
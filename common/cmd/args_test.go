@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"-config", "application.yml"}, []string{"--config", "application.yml"}},
+		{[]string{"-config=application.yml"}, []string{"--config=application.yml"}},
+		{[]string{"--config", "a.yml"}, []string{"--config", "a.yml"}},
+		{[]string{"config", "check", "-c", "a.yml"}, []string{"config", "check", "-c", "a.yml"}},
+		{[]string{"-h"}, []string{"-h"}},
+		{[]string{"-"}, []string{"-"}},
+	}
+	for _, tt := range tests {
+		if got := NormalizeArgs(tt.args); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("NormalizeArgs(%v) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
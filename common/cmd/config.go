@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"common/config"
+	"fmt"
+	"framework/game"
+	"github.com/spf13/cobra"
+)
+
+// NewConfigCommand 各服务共用的config子命令
+// config check 离线校验application.yml以及配置目录中的servers.json gameConfig.json，不连接任何外部服务
+func NewConfigCommand() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "配置文件相关操作",
+	}
+	var (
+		configFile    string
+		gameConfigDir string
+	)
+	checkCmd := &cobra.Command{
+		Use:          "check",
+		Short:        "校验配置文件",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			failed := false
+			if err := config.Check(configFile); err != nil {
+				failed = true
+				fmt.Fprintln(cmd.ErrOrStderr(), err)
+			}
+			if len(gameConfigDir) > 0 {
+				if err := game.CheckConfigDir(gameConfigDir); err != nil {
+					failed = true
+					fmt.Fprintln(cmd.ErrOrStderr(), err)
+				}
+			}
+			if failed {
+				return fmt.Errorf("config check failed")
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), "config check ok")
+			return nil
+		},
+	}
+	checkCmd.Flags().StringVar(&configFile, "config", "application.yml", "app config yml file")
+	checkCmd.Flags().StringVar(&gameConfigDir, "gameDir", "../config", "game config dir, empty to skip")
+	configCmd.AddCommand(checkCmd)
+	return configCmd
+}
@@ -1,10 +1,13 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
-	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/viper"
 	"log"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 )
 
 type Config struct {
@@ -20,6 +23,8 @@ type Config struct {
 	Etcd       EtcdConf                `mapstructure:"etcd"`
 	Domain     map[string]Domain       `mapstructure:"domain"`
 	Services   map[string]ServicesConf `mapstructure:"services"`
+
+	ConfigCenter ConfigCenterConf `mapstructure:"configCenter"`
 }
 
 type ServicesConf struct {
@@ -36,7 +41,14 @@ type JwtConf struct {
 	Exp    int64  `mapstructure:"exp"`
 }
 type LogConf struct {
-	Level string `mapstructure:"level"`
+	Level      string `mapstructure:"level"`
+	Format     string `mapstructure:"format"`     //text json
+	File       string `mapstructure:"file"`       //为空只输出到stderr
+	MaxSize    int    `mapstructure:"maxSize"`    //单个文件大小 MB
+	MaxBackups int    `mapstructure:"maxBackups"` //保留的旧文件个数
+	MaxAge     int    `mapstructure:"maxAge"`     //旧文件保留天数
+	Compress   bool   `mapstructure:"compress"`
+	Console    bool   `mapstructure:"console"` //写文件的同时输出到stderr
 }
 
 // Database 数据库配置
@@ -78,26 +90,120 @@ type GrpcConf struct {
 	Addr string `mapstructure:"addr"`
 }
 
-var Conf *Config
+// current 配置文件修改之后整体替换 读取方拿到的是同一个版本的配置
+var current atomic.Pointer[Config]
 
-func InitConfig(configFile string) {
-	Conf = new(Config)
-	v := viper.New()
-	v.SetConfigFile(configFile)
-	v.WatchConfig()
-	v.OnConfigChange(func(e fsnotify.Event) {
-		log.Println("配置文件被修改")
-		err := v.Unmarshal(&Conf)
-		if err != nil {
-			panic(fmt.Errorf("配置文件被修改以后，报错，err:%v \n", err))
+// Conf 当前的配置 不要长期持有返回值，配置修改之后会替换为新的对象
+func Conf() *Config {
+	return current.Load()
+}
+
+// InitConfig 读取本地配置文件，configCenter.provider为etcd时再合并配置中心中的配置
+// serverId为空时跳过serverId这一层
+func InitConfig(configFile string, serverId string) {
+	local, err := parseConfig(configFile, nil)
+	if err != nil {
+		panic(err)
+	}
+	if strings.ToLower(local.ConfigCenter.Provider) == "etcd" {
+		if err := initCenter(local, serverId); err != nil {
+			panic(fmt.Errorf("连接配置中心报错，err:%v \n", err))
 		}
-	})
-	err := v.ReadInConfig()
+	}
+	name := filepath.Base(configFile)
+	provider := NewProvider(filepath.Dir(configFile))
+	data, err := provider.Load(name)
 	if err != nil {
 		panic(fmt.Errorf("读取配置文件报错，err:%v \n", err))
 	}
-	if err := v.Unmarshal(&Conf); err != nil {
-		panic(fmt.Errorf("Unmarshal data to Conf failed ，err:%v \n", err))
+	conf, err := parseConfig(name, data)
+	if err != nil {
+		panic(err)
+	}
+	current.Store(conf)
+	provider.Watch(name, func(data []byte) {
+		conf, err := parseConfig(name, data)
+		if err != nil {
+			//修改之后的配置有错误 保留之前的配置
+			log.Printf("配置文件被修改以后，报错，err:%v", err)
+			return
+		}
+		log.Println("配置文件被修改")
+		current.Store(conf)
+	})
+}
+
+// parseConfig data为空时读取文件configFile，否则按configFile的扩展名解析data
+func parseConfig(configFile string, data []byte) (*Config, error) {
+	v := viper.New()
+	if data == nil {
+		v.SetConfigFile(configFile)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("read %s err:%v", configFile, err)
+		}
+	} else {
+		v.SetConfigType(strings.TrimPrefix(filepath.Ext(configFile), "."))
+		if err := v.ReadConfig(bytes.NewReader(data)); err != nil {
+			return nil, fmt.Errorf("read %s err:%v", configFile, err)
+		}
+	}
+	conf := new(Config)
+	if err := v.Unmarshal(conf); err != nil {
+		return nil, fmt.Errorf("parse %s err:%v", configFile, err)
+	}
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("%s %v", configFile, err)
 	}
+	return conf, nil
+}
+
+// Check 离线校验配置文件 config check子命令使用
+func Check(configFile string) error {
+	_, err := parseConfig(configFile, nil)
+	return err
+}
 
+// Validate 校验配置 返回所有错误
+func (c *Config) Validate() error {
+	errs := make([]string, 0)
+	if len(c.AppName) == 0 {
+		errs = append(errs, "appName: required")
+	}
+	ports := map[string]int{
+		"port":       c.Port,
+		"wsPort":     c.WsPort,
+		"metricPort": c.MetricPort,
+		"httpPort":   c.HttpPort,
+	}
+	for _, k := range []string{"port", "wsPort", "metricPort", "httpPort"} {
+		if ports[k] < 0 || ports[k] > 65535 {
+			errs = append(errs, fmt.Sprintf("%s: must be in 0-65535, got %d", k, ports[k]))
+		}
+	}
+	switch strings.ToUpper(c.Log.Level) {
+	case "", "DEBUG", "INFO", "WARN", "ERROR":
+	default:
+		errs = append(errs, fmt.Sprintf("log.level: expected DEBUG/INFO/WARN/ERROR, got %q", c.Log.Level))
+	}
+	switch strings.ToLower(c.Log.Format) {
+	case "", "text", "json":
+	default:
+		errs = append(errs, fmt.Sprintf("log.format: expected text/json, got %q", c.Log.Format))
+	}
+	switch strings.ToLower(c.ConfigCenter.Provider) {
+	case "", "file":
+	case "etcd":
+		if len(c.Etcd.Addrs) == 0 {
+			errs = append(errs, "etcd.addrs: required when configCenter.provider is etcd")
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("configCenter.provider: expected file/etcd, got %q", c.ConfigCenter.Provider))
+	}
+	if c.Log.MaxSize < 0 || c.Log.MaxBackups < 0 || c.Log.MaxAge < 0 {
+		errs = append(errs, "log: maxSize, maxBackups and maxAge must be >= 0")
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid:\n  %s", strings.Join(errs, "\n  "))
+	}
+	return nil
 }
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"io/fs"
+	"log"
+	"path"
+	"time"
+)
+
+const defaultCenterPrefix = "/jmqp/config"
+
+// ConfigCenterConf 配置中心 只从本地application.yml读取
+// etcd中的key: <prefix>/global/<name> <prefix>/<serverType>/<name> <prefix>/<serverType>/<serverId>/<name>
+// 读取时按 本地文件→global→serverType→serverId 的顺序合并，serverType为appName
+type ConfigCenterConf struct {
+	Provider string `mapstructure:"provider"` //file(默认) etcd
+	Prefix   string `mapstructure:"prefix"`   //默认/jmqp/config
+}
+
+type configCenter struct {
+	cli        *clientv3.Client
+	prefix     string
+	serverType string
+	serverId   string
+	timeout    time.Duration
+}
+
+// 开启配置中心之后不为空 NewProvider使用
+var center *configCenter
+
+func initCenter(conf *Config, serverId string) error {
+	cli, err := clientv3.New(clientv3.Config{
+		Endpoints:   conf.Etcd.Addrs,
+		DialTimeout: time.Duration(conf.Etcd.DialTimeout) * time.Second,
+	})
+	if err != nil {
+		return err
+	}
+	prefix := conf.ConfigCenter.Prefix
+	if len(prefix) == 0 {
+		prefix = defaultCenterPrefix
+	}
+	timeout := time.Duration(conf.Etcd.RWTimeout) * time.Second
+	if timeout <= 0 {
+		timeout = 3 * time.Second
+	}
+	center = &configCenter{
+		cli:        cli,
+		prefix:     prefix,
+		serverType: conf.AppName,
+		serverId:   serverId,
+		timeout:    timeout,
+	}
+	return nil
+}
+
+// keys 由低到高的层级
+func (c *configCenter) keys(name string) []string {
+	keys := []string{
+		path.Join(c.prefix, "global", name),
+		path.Join(c.prefix, c.serverType, name),
+	}
+	if len(c.serverId) > 0 {
+		keys = append(keys, path.Join(c.prefix, c.serverType, c.serverId, name))
+	}
+	return keys
+}
+
+// EtcdProvider etcd配置中心 etcd不可用时使用本地文件
+type EtcdProvider struct {
+	center *configCenter
+	local  *FileProvider
+}
+
+// Load 启动时读取 etcd不可用时使用本地文件
+func (p *EtcdProvider) Load(name string) ([]byte, error) {
+	return p.load(name, true)
+}
+
+// load fallback为false时etcd读取失败直接返回错误 避免修改配置时退回到只有本地文件的配置
+func (p *EtcdProvider) load(name string, fallback bool) ([]byte, error) {
+	layers := make([][]byte, 0, 4)
+	data, err := p.local.Load(name)
+	if err == nil {
+		layers = append(layers, data)
+	}
+	remote, err := p.loadRemote(name)
+	if err != nil {
+		if !fallback || len(layers) == 0 {
+			return nil, fmt.Errorf("load %s from etcd err:%v", name, err)
+		}
+		log.Printf("load %s from etcd err:%v, use local file", name, err)
+		return layers[0], nil
+	}
+	layers = append(layers, remote...)
+	if len(layers) == 0 {
+		return nil, fmt.Errorf("%s not found in local dir or etcd: %w", name, fs.ErrNotExist)
+	}
+	return mergeLayers(name, layers)
+}
+
+// loadRemote 在一个事务中读取所有层级，保证拿到的是同一个版本
+func (p *EtcdProvider) loadRemote(name string) ([][]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.center.timeout)
+	defer cancel()
+	keys := p.center.keys(name)
+	ops := make([]clientv3.Op, 0, len(keys))
+	for _, key := range keys {
+		ops = append(ops, clientv3.OpGet(key))
+	}
+	res, err := p.center.cli.Txn(ctx).Then(ops...).Commit()
+	if err != nil {
+		return nil, err
+	}
+	layers := make([][]byte, 0, len(keys))
+	for _, r := range res.Responses {
+		for _, kv := range r.GetResponseRange().Kvs {
+			layers = append(layers, kv.Value)
+		}
+	}
+	return layers, nil
+}
+
+// Watch 任意一层(包括本地文件)发生变化都重新读取合并后的配置
+func (p *EtcdProvider) Watch(name string, fn func(data []byte)) {
+	reload := func() {
+		data, err := p.load(name, false)
+		if err != nil {
+			//保留之前的配置 等待下一次变化或者重新watch之后再读取
+			log.Printf("reload %s err:%v", name, err)
+			return
+		}
+		fn(data)
+	}
+	p.local.Watch(name, func([]byte) { reload() })
+	keys := make(map[string]bool)
+	for _, key := range p.center.keys(name) {
+		keys[key] = true
+	}
+	go func() {
+		for {
+			for res := range p.center.cli.Watch(context.Background(), p.center.prefix+"/", clientv3.WithPrefix()) {
+				if err := res.Err(); err != nil {
+					log.Printf("watch %s err:%v", name, err)
+					continue
+				}
+				for _, event := range res.Events {
+					if keys[string(event.Kv.Key)] {
+						reload()
+						break
+					}
+				}
+			}
+			//watch通道被关闭(例如连接断开后版本被压缩)，重新watch并补一次读取
+			time.Sleep(time.Second)
+			reload()
+		}
+	}()
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Provider 配置来源 name为配置文件名，例如application.yml gameConfig.json servers.json
+// 返回的内容和本地文件格式一致，调用方按原来解析文件的方式解析
+type Provider interface {
+	// Load 读取配置 不存在时返回的error满足errors.Is(err, fs.ErrNotExist)
+	Load(name string) ([]byte, error)
+	// Watch 配置变化后回调最新的完整内容
+	Watch(name string, fn func(data []byte))
+}
+
+// NewProvider 开启配置中心时返回etcd，本地目录dir中的同名文件作为最底层
+// 未开启时直接读取本地目录，本地开发使用
+func NewProvider(dir string) Provider {
+	local := NewFileProvider(dir)
+	if center == nil {
+		return local
+	}
+	return &EtcdProvider{center: center, local: local}
+}
+
+// FileProvider 本地目录
+type FileProvider struct {
+	dir string
+}
+
+func NewFileProvider(dir string) *FileProvider {
+	return &FileProvider{dir: dir}
+}
+
+func (p *FileProvider) Load(name string) ([]byte, error) {
+	return os.ReadFile(path.Join(p.dir, name))
+}
+
+func (p *FileProvider) Watch(name string, fn func(data []byte)) {
+	watchDir(p.dir, func(changed string) {
+		if changed != name {
+			return
+		}
+		data, err := p.Load(name)
+		if err != nil {
+			//编辑器重命名保存的中间状态 等下一次事件
+			return
+		}
+		fn(data)
+	})
+}
+
+// mergeLayers 按顺序把后面的层合并到前面，对象逐个key递归合并，其他类型(包括数组)整体覆盖
+func mergeLayers(name string, layers [][]byte) ([]byte, error) {
+	if len(layers) == 1 {
+		return layers[0], nil
+	}
+	merged := make(map[string]any)
+	for _, data := range layers {
+		m, err := decode(name, data)
+		if err != nil {
+			return nil, err
+		}
+		merged = merge(merged, m)
+	}
+	if isJSON(name) {
+		return json.Marshal(merged)
+	}
+	return yaml.Marshal(merged)
+}
+
+func merge(dst, src map[string]any) map[string]any {
+	for k, v := range src {
+		if sv, ok := v.(map[string]any); ok {
+			if dv, ok := dst[k].(map[string]any); ok {
+				dst[k] = merge(dv, sv)
+				continue
+			}
+		}
+		dst[k] = v
+	}
+	return dst
+}
+
+func decode(name string, data []byte) (map[string]any, error) {
+	m := make(map[string]any)
+	if isJSON(name) {
+		//保留数字原样 避免大整数转成float64丢精度
+		d := json.NewDecoder(bytes.NewReader(data))
+		d.UseNumber()
+		if err := d.Decode(&m); err != nil {
+			return nil, fmt.Errorf("parse %s err:%v", name, err)
+		}
+		return m, nil
+	}
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parse %s err:%v", name, err)
+	}
+	return m, nil
+}
+
+func isJSON(name string) bool {
+	return strings.ToLower(filepath.Ext(name)) == ".json"
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"github.com/fsnotify/fsnotify"
+	"log"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// 编辑器保存文件时可能触发多次写入或者先删除再重命名，所以监听目录并合并短时间内的事件
+const reloadDelay = 200 * time.Millisecond
+
+func watchDir(configDir string, reload func(name string)) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Printf("create config watcher err:%v", err)
+		return
+	}
+	if err := watcher.Add(configDir); err != nil {
+		log.Printf("watch config dir err:%v,dir=%s", err, configDir)
+		watcher.Close()
+		return
+	}
+	go func() {
+		var lock sync.Mutex
+		timers := make(map[string]*time.Timer)
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) && !event.Has(fsnotify.Rename) {
+					continue
+				}
+				name := filepath.Base(event.Name)
+				lock.Lock()
+				if t, ok := timers[name]; ok {
+					t.Reset(reloadDelay)
+				} else {
+					timers[name] = time.AfterFunc(reloadDelay, func() {
+						lock.Lock()
+						delete(timers, name)
+						lock.Unlock()
+						reload(name)
+					})
+				}
+				lock.Unlock()
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("config watcher err:%v", err)
+			}
+		}
+	}()
+}
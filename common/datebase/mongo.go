@@ -19,16 +19,16 @@ type MongoManager struct {
 func NewMongo() *MongoManager {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	clientOptions := options.Client().ApplyURI(config.Conf.Database.MongoConf.Url)
-	fmt.Println(config.Conf.Database.MongoConf.Url)
-	fmt.Println(config.Conf.Database.MongoConf.UserName)
+	clientOptions := options.Client().ApplyURI(config.Conf().Database.MongoConf.Url)
+	fmt.Println(config.Conf().Database.MongoConf.Url)
+	fmt.Println(config.Conf().Database.MongoConf.UserName)
 
 	clientOptions.SetAuth(options.Credential{
-		Username: config.Conf.Database.MongoConf.UserName,
-		Password: config.Conf.Database.MongoConf.Password,
+		Username: config.Conf().Database.MongoConf.UserName,
+		Password: config.Conf().Database.MongoConf.Password,
 	})
-	clientOptions.SetMinPoolSize(config.Conf.Database.MongoConf.MinPoolSize)
-	clientOptions.SetMinPoolSize(config.Conf.Database.MongoConf.MaxPoolSize)
+	clientOptions.SetMinPoolSize(config.Conf().Database.MongoConf.MinPoolSize)
+	clientOptions.SetMinPoolSize(config.Conf().Database.MongoConf.MaxPoolSize)
 	client, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
 		logs.Fatal("mongo connect err:%v", err)
@@ -40,7 +40,7 @@ func NewMongo() *MongoManager {
 	m := &MongoManager{
 		Cli: client,
 	}
-	m.Db = m.Cli.Database(config.Conf.Database.MongoConf.Db)
+	m.Db = m.Cli.Database(config.Conf().Database.MongoConf.Db)
 	return m
 }
 
@@ -16,22 +16,22 @@ type RedisManager struct {
 func NewRedis() *RedisManager {
 	var clusterCli *redis.ClusterClient
 	var cli *redis.Client
-	clusterAddrs := config.Conf.Database.RedisConf.ClusterAddrs
+	clusterAddrs := config.Conf().Database.RedisConf.ClusterAddrs
 	if len(clusterAddrs) <= 0 {
 		//单节点
 		cli = redis.NewClient(&redis.Options{
-			Addr:         config.Conf.Database.RedisConf.Addr,
-			PoolSize:     config.Conf.Database.RedisConf.PoolSize,
-			MinIdleConns: config.Conf.Database.RedisConf.MinIdleConns,
-			Password:     config.Conf.Database.RedisConf.Password,
+			Addr:         config.Conf().Database.RedisConf.Addr,
+			PoolSize:     config.Conf().Database.RedisConf.PoolSize,
+			MinIdleConns: config.Conf().Database.RedisConf.MinIdleConns,
+			Password:     config.Conf().Database.RedisConf.Password,
 		})
 	} else {
 		//集群
 		clusterCli = redis.NewClusterClient(&redis.ClusterOptions{
-			Addrs:        config.Conf.Database.RedisConf.ClusterAddrs,
-			PoolSize:     config.Conf.Database.RedisConf.PoolSize,
-			MinIdleConns: config.Conf.Database.RedisConf.MinIdleConns,
-			Password:     config.Conf.Database.RedisConf.Password,
+			Addrs:        config.Conf().Database.RedisConf.ClusterAddrs,
+			PoolSize:     config.Conf().Database.RedisConf.PoolSize,
+			MinIdleConns: config.Conf().Database.RedisConf.MinIdleConns,
+			Password:     config.Conf().Database.RedisConf.Password,
 		})
 	}
 	if clusterCli != nil {
@@ -63,6 +63,14 @@ func (r *RedisManager) Close() {
 	}
 }
 
+// Client 单机和集群统一的命令接口
+func (r *RedisManager) Client() redis.Cmdable {
+	if r.ClusterCli != nil {
+		return r.ClusterCli
+	}
+	return r.Cli
+}
+
 func (r *RedisManager) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
 	if r.ClusterCli != nil {
 		return r.ClusterCli.Set(ctx, key, value, expiration).Err()
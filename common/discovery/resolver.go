@@ -30,7 +30,7 @@ func (r Resolver) Build(target resolver.Target, cc resolver.ClientConn, opts res
 		DialTimeout: time.Duration(r.DialTimeout) * time.Second,
 	})
 	if err != nil {
-		logs.Fatal("create etcd resolver client error:", err)
+		logs.Fatal("create etcd resolver client error:%v", err)
 	}
 	r.closeCh = make(chan struct{})
 	//根据key获取value
@@ -52,7 +52,7 @@ func (r Resolver) sync() error {
 	defer cancel()
 	res, err := r.etcdCli.Get(ctx, r.key, clientv3.WithPrefix())
 	if err != nil {
-		logs.Error("get etcd key error:", r.key, err)
+		logs.Error("get etcd key error:%v,key=%s", err, r.key)
 		return err
 	}
 	//logs.Info("%v", res.Kvs)
@@ -60,7 +60,7 @@ func (r Resolver) sync() error {
 	for _, v := range res.Kvs {
 		server, err := ParseValue(v.Value)
 		if err != nil {
-			logs.Error("parse value error:", v.Key, err)
+			logs.Error("parse value error:%v,key=%s", err, v.Key)
 			continue
 		}
 		r.srvAddrList = append(r.srvAddrList, resolver.Address{
@@ -69,14 +69,14 @@ func (r Resolver) sync() error {
 		})
 	}
 	if len(r.srvAddrList) == 0 {
-		logs.Error("get etcd addr error:", r.key)
+		logs.Error("get etcd addr error,key=%s", r.key)
 		return nil
 	}
 	err = r.cc.UpdateState(resolver.State{
 		Addresses: r.srvAddrList,
 	})
 	if err != nil {
-		logs.Error("update etcd resolver state error:", r.key, err)
+		logs.Error("update etcd resolver state error:%v,key=%s", err, r.key)
 	}
 	return nil
 }
@@ -104,7 +104,7 @@ func (r Resolver) watch() {
 			}
 		case <-ticker.C:
 			if err := r.sync(); err != nil {
-				logs.Error("update etcd resolver state error:", r.key, err)
+				logs.Error("update etcd resolver state error:%v,key=%s", err, r.key)
 			}
 		}
 	}
@@ -116,7 +116,7 @@ func (r Resolver) update(events []*clientv3.Event) {
 		case clientv3.EventTypePut:
 			server, err := ParseValue(event.Kv.Value)
 			if err != nil {
-				logs.Error("parse value error:", event.Kv.Key, err)
+				logs.Error("parse value error:%v,key=%s", err, event.Kv.Key)
 			}
 			address := resolver.Address{
 				Addr:       server.Addr,
@@ -128,14 +128,14 @@ func (r Resolver) update(events []*clientv3.Event) {
 					Addresses: r.srvAddrList,
 				})
 				if err != nil {
-					logs.Error("update etcd resolver state error:", r.key, err)
+					logs.Error("update etcd resolver state error:%v,key=%s", err, r.key)
 				}
 			}
 		case clientv3.EventTypeDelete:
 			//接收到delete操作，删除r.survivalist其中匹配的
 			server, err := ParseKey(string(event.Kv.Key))
 			if err != nil {
-				logs.Error("parse value error:", event.Kv.Key, err)
+				logs.Error("parse value error:%v,key=%s", err, event.Kv.Key)
 			}
 			addr := resolver.Address{
 				Addr: server.Addr,
@@ -146,7 +146,7 @@ func (r Resolver) update(events []*clientv3.Event) {
 					Addresses: r.srvAddrList,
 				})
 				if err != nil {
-					logs.Error("update etcd resolver state error:", r.key, err)
+					logs.Error("update etcd resolver state error:%v,key=%s", err, r.key)
 				}
 			}
 		}
@@ -173,7 +173,7 @@ func (r Resolver) Close() {
 	if r.etcdCli != nil {
 		err := r.etcdCli.Close()
 		if err != nil {
-			logs.Error("close etcd client error:", err)
+			logs.Error("close etcd client error:%v", err)
 		}
 	}
 
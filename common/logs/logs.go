@@ -2,25 +2,152 @@ package logs
 
 import (
 	"common/config"
+	"common/trace"
+	"context"
 	"github.com/charmbracelet/log"
+	"gopkg.in/natefinch/lumberjack.v2"
+	"io"
 	"os"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
-var logger *log.Logger
+// 常用的结构化字段key，各服务统一使用，方便日志检索
+const (
+	KeyUid     = "uid"
+	KeyRoomId  = "roomId"
+	KeyRoute   = "route"
+	KeyTraceId = "traceId"
+)
+
+// InitLog之前的日志也能正常输出到stderr
+var logger = log.New(os.Stderr)
 
+// InitLog 根据LogConf初始化日志
+// level: DEBUG INFO WARN ERROR，format: text(默认) json，file不为空时按大小切割写入文件
 func InitLog(appName string) {
-	logger = log.New(os.Stderr)
-	if config.Conf.Log.Level == "DEBUG" {
-		logger.SetLevel(log.DebugLevel)
+	conf := config.Conf().Log
+	logger = log.NewWithOptions(output(conf), log.Options{
+		Prefix:          appName,
+		ReportTimestamp: true,
+		TimeFormat:      time.DateTime,
+		Level:           level(conf.Level),
+	})
+	if strings.ToLower(conf.Format) == "json" {
+		logger.SetFormatter(log.JSONFormatter)
+	}
+}
+
+func level(l string) log.Level {
+	switch strings.ToUpper(l) {
+	case "DEBUG":
+		return log.DebugLevel
+	case "WARN":
+		return log.WarnLevel
+	case "ERROR":
+		return log.ErrorLevel
+	default:
+		return log.InfoLevel
+	}
+}
+
+func output(conf config.LogConf) io.Writer {
+	if len(conf.File) == 0 {
+		return os.Stderr
+	}
+	file := &lumberjack.Logger{
+		Filename:   conf.File,
+		MaxSize:    conf.MaxSize,
+		MaxBackups: conf.MaxBackups,
+		MaxAge:     conf.MaxAge,
+		Compress:   conf.Compress,
+		LocalTime:  true,
+	}
+	if conf.Console {
+		return io.MultiWriter(os.Stderr, file)
+	}
+	return file
+}
+
+// Logger 带结构化字段的日志
+// 字段在输出时才绑定到全局logger，包级变量中创建的Logger在InitLog之后同样生效
+type Logger struct {
+	fields []any
+	every  uint64
+	count  *atomic.Uint64
+}
+
+// With 附加key/value字段，例如 logs.With(logs.KeyUid, uid, logs.KeyRoomId, roomId)
+func With(kv ...any) *Logger {
+	return &Logger{fields: kv}
+}
+
+func (l *Logger) With(kv ...any) *Logger {
+	fields := make([]any, 0, len(l.fields)+len(kv))
+	fields = append(fields, l.fields...)
+	fields = append(fields, kv...)
+	return &Logger{fields: fields, every: l.every, count: l.count}
+}
+
+// WithTrace 带traceId字段，同一请求在connector、nats节点、grpc服务上的日志可以通过traceId关联
+func WithTrace(traceId string) *Logger {
+	if len(traceId) == 0 {
+		return &Logger{}
+	}
+	return With(KeyTraceId, traceId)
+}
+
+// Ctx 从context中取traceId(grpc服务端拦截器和gate中间件会写入)
+func Ctx(ctx context.Context) *Logger {
+	return WithTrace(trace.FromContext(ctx))
+}
 
+// Sample 高频路径使用，每every条只输出一条，需要保存为包级变量复用
+func Sample(every uint64) *Logger {
+	if every <= 1 {
+		return &Logger{}
+	}
+	return &Logger{every: every, count: new(atomic.Uint64)}
+}
+
+func (l *Logger) Debug(format string, values ...any) {
+	l.log(log.DebugLevel, format, values...)
+}
+
+func (l *Logger) Info(format string, values ...any) {
+	l.log(log.InfoLevel, format, values...)
+}
+
+func (l *Logger) Warn(format string, values ...any) {
+	l.log(log.WarnLevel, format, values...)
+}
+
+func (l *Logger) Error(format string, values ...any) {
+	l.log(log.ErrorLevel, format, values...)
+}
+
+func (l *Logger) log(lv log.Level, format string, values ...any) {
+	if lv < logger.GetLevel() {
+		return
+	}
+	if l.every > 1 && (l.count.Add(1)-1)%l.every != 0 {
+		return
+	}
+	emit(logger, lv, l.fields, format, values...)
+}
+
+func emit(base *log.Logger, lv log.Level, fields []any, format string, values ...any) {
+	if len(fields) > 0 {
+		base = base.With(fields...)
+	}
+	if len(values) == 0 {
+		base.Log(lv, format)
 	} else {
-		logger.SetLevel(log.InfoLevel)
+		base.Logf(lv, format, values...)
 	}
-	logger.SetPrefix(appName)
-	logger.SetReportTimestamp(true)
-	logger.SetTimeFormat(time.DateTime)
 }
+
 func Fatal(format string, values ...any) {
 	if len(values) == 0 {
 		logger.Fatal(format)
@@ -28,6 +155,14 @@ func Fatal(format string, values ...any) {
 		logger.Fatalf(format, values...)
 	}
 
+}
+func Debug(format string, values ...any) {
+	if len(values) == 0 {
+		logger.Debug(format)
+	} else {
+		logger.Debugf(format, values...)
+	}
+
 }
 func Info(format string, values ...any) {
 	if len(values) == 0 {
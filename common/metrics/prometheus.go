@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+// 所有服务共用的指标定义，通过metric端口的/metrics暴露给prometheus
+const namespace = "jmqp"
+
+var (
+	// ConnectorConnections connector当前websocket连接数
+	ConnectorConnections = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "connector",
+		Name:      "connections",
+		Help:      "Current number of websocket connections.",
+	})
+	// ConnectorMessages connector收到的客户端消息数 按路由统计
+	ConnectorMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "connector",
+		Name:      "messages_total",
+		Help:      "Client messages received by route.",
+	}, []string{"route"})
+	// HandlerDuration 业务handler处理耗时 connector本地handler和nats节点handler
+	HandlerDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "handler_duration_seconds",
+		Help:      "Handler latency by route.",
+		Buckets:   []float64{.0005, .001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"route"})
+	// NatsEvents nats连接事件以及发送失败次数
+	NatsEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "nats",
+		Name:      "events_total",
+		Help:      "NATS connection events and publish errors.",
+	}, []string{"event"})
+	// Rooms 当前房间数 按游戏类型
+	Rooms = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "game",
+		Name:      "rooms",
+		Help:      "Current number of rooms by game type.",
+	}, []string{"gameType"})
+	// GamesStarted 开始的牌局数
+	GamesStarted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "game",
+		Name:      "started_total",
+		Help:      "Games started by game type.",
+	}, []string{"gameType"})
+	// GamesFinished 结束的牌局数
+	GamesFinished = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "game",
+		Name:      "finished_total",
+		Help:      "Games finished by game type.",
+	}, []string{"gameType"})
+	grpcRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "requests_total",
+		Help:      "gRPC requests by method and code.",
+	}, []string{"method", "code"})
+	grpcDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "duration_seconds",
+		Help:      "gRPC handler latency by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+)
+
+// ObserveHandler 记录handler耗时 用法 defer metrics.ObserveHandler(route, time.Now())
+func ObserveHandler(route string, start time.Time) {
+	HandlerDuration.WithLabelValues(route).Observe(time.Since(start).Seconds())
+}
+
+// UnaryServerInterceptor grpc服务端请求数和耗时统计
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		grpcRequests.WithLabelValues(info.FullMethod, status.Code(err).String()).Inc()
+		grpcDuration.WithLabelValues(info.FullMethod).Observe(time.Since(start).Seconds())
+		return resp, err
+	}
+}
@@ -2,6 +2,7 @@ package metrics
 
 import (
 	"github.com/arl/statsviz"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"net/http"
 )
 
@@ -11,6 +12,7 @@ func Serve(addr string) error {
 	if err != nil {
 		return err
 	}
+	mux.Handle("/metrics", promhttp.Handler())
 	if err := http.ListenAndServe(addr, mux); err != nil {
 		return err
 	}
@@ -4,6 +4,7 @@ import (
 	"common/config"
 	"common/discovery"
 	"common/logs"
+	"common/trace"
 	"context"
 	"fmt"
 	"google.golang.org/grpc"
@@ -18,22 +19,23 @@ var (
 
 func Init() {
 	//etcd解析器
-	r := discovery.NewResolver(config.Conf.Etcd)
+	r := discovery.NewResolver(config.Conf().Etcd)
 	resolver.Register(r)
-	userDomain := config.Conf.Domain["user"]
+	userDomain := config.Conf().Domain["user"]
 	initClient(userDomain.Name, userDomain.LoadBalance, &UserClient)
 }
 
 func initClient(name string, loadBalance bool, client interface{}) {
 	addr := fmt.Sprintf("etcd:///%s", name)
 	opts := []grpc.DialOption{
-		grpc.WithTransportCredentials(insecure.NewCredentials())}
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(trace.UnaryClientInterceptor())}
 	if loadBalance {
 		opts = append(opts, grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"LoadBalancingPolicy": "%s"}`, "round_robin")))
 	}
 	conn, err := grpc.DialContext(context.TODO(), addr, opts...)
 	if err != nil {
-		logs.Fatal("rpc connect err:", err)
+		logs.Fatal("rpc connect err:%v", err)
 	}
 	switch c := client.(type) {
 	case *pb.UserServiceClient:
@@ -0,0 +1,76 @@
+package trace
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"strings"
+)
+
+// MetadataKey grpc metadata以及http header中携带traceId的key
+const MetadataKey = "x-trace-id"
+
+type traceKey struct{}
+
+// NewId 生成traceId，在请求进入系统的地方(connector,gate)生成，之后一路透传
+func NewId() string {
+	return strings.ReplaceAll(uuid.NewString(), "-", "")
+}
+
+// traceIdMaxLength 外部传入的traceId最大长度
+const traceIdMaxLength = 64
+
+// Valid 外部(http header、grpc metadata)传入的traceId只允许字母数字和-_，超长或者含有其他字符的丢弃重新生成
+func Valid(traceId string) bool {
+	if len(traceId) == 0 || len(traceId) > traceIdMaxLength {
+		return false
+	}
+	for _, c := range traceId {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+func WithContext(ctx context.Context, traceId string) context.Context {
+	if len(traceId) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, traceKey{}, traceId)
+}
+
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	traceId, _ := ctx.Value(traceKey{}).(string)
+	return traceId
+}
+
+// UnaryClientInterceptor 将context中的traceId写入grpc metadata
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if traceId := FromContext(ctx); len(traceId) > 0 {
+			ctx = metadata.AppendToOutgoingContext(ctx, MetadataKey, traceId)
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// UnaryServerInterceptor 从grpc metadata中读取traceId放入context，没有则生成新的
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var traceId string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(MetadataKey); len(values) > 0 {
+				traceId = values[0]
+			}
+		}
+		if !Valid(traceId) {
+			traceId = NewId()
+		}
+		return handler(WithContext(ctx, traceId), req)
+	}
+}
@@ -3,10 +3,12 @@ package app
 import (
 	"common/config"
 	"common/logs"
+	"connector/broadcast"
 	"connector/route"
 	"context"
 	"core/repo"
 	"framework/connector"
+	"framework/game"
 	"os"
 	"os/signal"
 	"syscall"
@@ -15,32 +17,55 @@ import (
 
 func Run(ctx context.Context, serverId string) error {
 	//日志
-	logs.InitLog(config.Conf.AppName)
-	exit := func() {}
+	logs.InitLog(config.Conf().AppName)
+	c := connector.Default()
+	manager := repo.New()
+	//跑马灯公告
+	scheduler := broadcast.NewScheduler(c, manager)
 	go func() {
-		c := connector.Default()
-		exit = c.Close
-		manager := repo.New()
-		c.RegisterHandler(route.RegisterHandler(manager))
+		c.RegisterHandler(route.RegisterHandler(manager, scheduler))
+		c.RegisterAdminHandler("POST /admin/broadcasts", scheduler.AdminCreate)
+		c.RegisterAdminHandler("GET /admin/broadcasts", scheduler.AdminList)
+		scheduler.Run()
 		c.Run(serverId)
 	}()
 
 	stop := func() {
-		exit()
+		scheduler.Close()
+		c.Close()
 		time.Sleep(3 * time.Second)
 		logs.Info("stop app finish")
 	}
-	//优雅启停
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, syscall.SIGTERM, syscall.SIGQUIT, syscall.SIGINT, syscall.SIGHUP)
+	//不再接受新连接 等客户端重连到其他connector之后退出
+	drain := func() {
+		timeout := 0
+		if conf := game.Conf.GetConnector(serverId); conf != nil {
+			timeout = conf.DrainTimeout
+		}
+		drainCtx, cancel := context.WithTimeout(ctx, game.DrainTimeout(timeout))
+		defer cancel()
+		c.Drain(drainCtx)
+		stop()
+	}
+	//优雅启停 SIGTERM先drain再退出
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGTERM, syscall.SIGQUIT, syscall.SIGINT, syscall.SIGHUP)
 	for {
 		select {
 		case <-ctx.Done():
 			stop()
 			return nil
-		case s := <-c:
+		case <-c.Draining():
+			drain()
+			logs.Info("connector app drained")
+			return nil
+		case s := <-sig:
 			switch s {
-			case syscall.SIGTERM, syscall.SIGQUIT, syscall.SIGINT:
+			case syscall.SIGTERM:
+				drain()
+				logs.Info("connector app drained")
+				return nil
+			case syscall.SIGQUIT, syscall.SIGINT:
 				stop()
 				logs.Info("connector app quit")
 				return nil
@@ -0,0 +1,30 @@
+package broadcast
+
+import (
+	"common"
+	"common/biz"
+	"core/models/requests"
+	"encoding/json"
+	"framework/connector"
+	"net/http"
+)
+
+// AdminCreate POST /admin/broadcasts 新增公告
+func (s *Scheduler) AdminCreate(w http.ResponseWriter, r *http.Request) {
+	var req requests.BroadcastReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		connector.WriteFail(w, biz.RequestDataError)
+		return
+	}
+	b, err := s.Create(r.Context(), req)
+	if err != nil {
+		connector.WriteFail(w, err)
+		return
+	}
+	connector.WriteResult(w, http.StatusOK, common.Successed(b))
+}
+
+// AdminList GET /admin/broadcasts 当前生效中的公告
+func (s *Scheduler) AdminList(w http.ResponseWriter, r *http.Request) {
+	connector.WriteResult(w, http.StatusOK, common.Successed(s.Active()))
+}
@@ -0,0 +1,240 @@
+package broadcast
+
+import (
+	"common/logs"
+	"context"
+	"core/models/entity"
+	"core/models/requests"
+	"core/repo"
+	"core/service"
+	"framework/game"
+	"framework/msError"
+	"framework/nets"
+	"sync"
+	"time"
+)
+
+const (
+	pushRoute      = "ServerMessagePush"
+	pushRouter     = "BroadcastPush"
+	reloadInterval = 30 * time.Second
+	tickInterval   = time.Second
+	entryPushDelay = time.Second
+)
+
+// Pusher 推送给当前connector上满足条件的用户
+type Pusher interface {
+	Push(filter func(*nets.Session) bool, route string, data any) (int, error)
+}
+
+// Scheduler 跑马灯公告调度
+// 每个connector定时从mongo加载生效中的公告，只推送给自己的在线用户，多个connector之间不会重复
+// gameConfig中的loopBroadcastContent作为默认公告，按loopBroadcastInterval循环推送给所有人
+type Scheduler struct {
+	sync.Mutex
+	pusher    Pusher
+	service   *service.BroadcastService
+	active    []*entity.Broadcast
+	nextSend  map[string]int64 //公告id -> 下次推送时间 毫秒
+	nextLoop  int64
+	closeChan chan struct{}
+	closeOnce sync.Once
+}
+
+func NewScheduler(pusher Pusher, r *repo.Manager) *Scheduler {
+	return &Scheduler{
+		pusher:    pusher,
+		service:   service.NewBroadcastService(r),
+		nextSend:  make(map[string]int64),
+		closeChan: make(chan struct{}),
+	}
+}
+
+func (s *Scheduler) Run() {
+	s.Reload()
+	go s.run()
+}
+
+func (s *Scheduler) run() {
+	reload := time.NewTicker(reloadInterval)
+	tick := time.NewTicker(tickInterval)
+	defer reload.Stop()
+	defer tick.Stop()
+	for {
+		select {
+		case <-s.closeChan:
+			return
+		case <-reload.C:
+			s.Reload()
+		case <-tick.C:
+			s.tick(time.Now().UnixMilli())
+		}
+	}
+}
+
+func (s *Scheduler) Close() {
+	s.closeOnce.Do(func() {
+		close(s.closeChan)
+	})
+}
+
+// Reload 重新加载生效中的公告，已经推送过的公告保留下次推送时间
+func (s *Scheduler) Reload() {
+	list, err := s.service.FindActive(context.TODO())
+	if err != nil {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	nextSend := make(map[string]int64, len(list))
+	for _, v := range list {
+		id := v.Id.Hex()
+		if next, ok := s.nextSend[id]; ok {
+			nextSend[id] = next
+		}
+	}
+	s.active = list
+	s.nextSend = nextSend
+}
+
+func (s *Scheduler) tick(now int64) {
+	s.Lock()
+	due := make([]*entity.Broadcast, 0)
+	for _, v := range s.active {
+		if now < v.StartTime || now >= v.EndTime {
+			continue
+		}
+		id := v.Id.Hex()
+		if next, ok := s.nextSend[id]; ok && now < next {
+			continue
+		}
+		if v.Interval > 0 {
+			s.nextSend[id] = now + int64(v.Interval)*1000
+		} else {
+			s.nextSend[id] = v.EndTime
+		}
+		due = append(due, v)
+	}
+	loopContent, loopInterval := loopBroadcast()
+	sendLoop := len(loopContent) > 0 && now >= s.nextLoop
+	if sendLoop {
+		s.nextLoop = now + int64(loopInterval)*1000
+	}
+	s.Unlock()
+
+	for _, v := range due {
+		b := v
+		s.push(b.Content, func(session *nets.Session) bool {
+			return match(b, session)
+		})
+	}
+	if sendLoop {
+		s.push(loopContent, func(session *nets.Session) bool {
+			return true
+		})
+	}
+}
+
+// OnEntry 用户登录之后推送当前生效中的公告，延迟一会等登录响应先到达客户端
+func (s *Scheduler) OnEntry(uid string) {
+	time.AfterFunc(entryPushDelay, func() {
+		now := time.Now().UnixMilli()
+		s.Lock()
+		list := make([]*entity.Broadcast, 0, len(s.active))
+		for _, v := range s.active {
+			if now >= v.StartTime && now < v.EndTime {
+				list = append(list, v)
+			}
+		}
+		s.Unlock()
+		for _, v := range list {
+			b := v
+			s.push(b.Content, func(session *nets.Session) bool {
+				return session.Uid == uid && match(b, session)
+			})
+		}
+	})
+}
+
+// Create 新增公告并立即生效
+func (s *Scheduler) Create(ctx context.Context, req requests.BroadcastReq) (*entity.Broadcast, *msError.Error) {
+	b, err := s.service.Create(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	s.Reload()
+	return b, nil
+}
+
+// Active 当前生效中的公告
+func (s *Scheduler) Active() []*entity.Broadcast {
+	s.Lock()
+	defer s.Unlock()
+	list := make([]*entity.Broadcast, len(s.active))
+	copy(list, s.active)
+	return list
+}
+
+func (s *Scheduler) push(content string, filter func(*nets.Session) bool) {
+	_, err := s.pusher.Push(filter, pushRoute, map[string]any{
+		"content":    content,
+		"pushRouter": pushRouter,
+	})
+	if err != nil {
+		logs.Error("push broadcast err:%v", err)
+	}
+}
+
+func match(b *entity.Broadcast, session *nets.Session) bool {
+	switch b.Target {
+	case entity.BroadcastTargetAll:
+		return true
+	case entity.BroadcastTargetUnion:
+		v, ok := session.Get("unionIds")
+		if !ok {
+			return false
+		}
+		for _, id := range toInt64s(v) {
+			if id == b.UnionID {
+				return true
+			}
+		}
+		return false
+	case entity.BroadcastTargetGameType:
+		v, ok := session.Get("gameType")
+		if !ok {
+			return false
+		}
+		//session数据经过json传输 数字为float64
+		switch gameType := v.(type) {
+		case float64:
+			return int(gameType) == b.GameType
+		case int:
+			return gameType == b.GameType
+		}
+		return false
+	}
+	return false
+}
+
+// toInt64s 本地设置的是[]int64 经过节点json传输之后变为[]any 元素为float64
+func toInt64s(v any) []int64 {
+	switch ids := v.(type) {
+	case []int64:
+		return ids
+	case []any:
+		res := make([]int64, 0, len(ids))
+		for _, id := range ids {
+			if f, ok := id.(float64); ok {
+				res = append(res, int64(f))
+			}
+		}
+		return res
+	}
+	return nil
+}
+
+func loopBroadcast() (string, int) {
+	settings := game.Conf.Settings()
+	return settings.LoopBroadcastContent, int(settings.LoopBroadcastInterval)
+}
@@ -0,0 +1,28 @@
+package broadcast
+
+import (
+	"core/models/entity"
+	"encoding/json"
+	"framework/nets"
+	"testing"
+)
+
+func TestMatchUnion(t *testing.T) {
+	b := &entity.Broadcast{Target: entity.BroadcastTargetUnion, UnionID: 2}
+	session := nets.NewSession("cid")
+	session.Put("unionIds", []int64{1, 2})
+	if !match(b, session) {
+		t.Fatal("[]int64 unionIds should match")
+	}
+	//经过节点回传的session数据
+	var data map[string]any
+	_ = json.Unmarshal([]byte(`{"unionIds":[3,2]}`), &data)
+	session.SetData(session.Uid, data)
+	if !match(b, session) {
+		t.Fatal("json unionIds should match")
+	}
+	session.Put("unionIds", []any{float64(3)})
+	if match(b, session) {
+		t.Fatal("other union should not match")
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"common/config"
 	"common/jwts"
 	"common/logs"
+	"common/trace"
+	"connector/broadcast"
 	"connector/models/request"
 	"context"
 	"core/repo"
@@ -13,22 +15,24 @@ import (
 	"encoding/json"
 	"framework/game"
 	"framework/nets"
+	"framework/remote"
 )
 
 type EntryHandler struct {
 	userService *service.UserService
+	broadcast   *broadcast.Scheduler
 }
 
-func NewEntryHandler(r *repo.Manager) *EntryHandler {
+func NewEntryHandler(r *repo.Manager, scheduler *broadcast.Scheduler) *EntryHandler {
 	return &EntryHandler{
 		userService: service.NewUserService(r),
+		broadcast:   scheduler,
 	}
 }
 
-func (h *EntryHandler) Entry(session *nets.Session, body []byte) (any, error) {
-	logs.Info("++++++++++++++++++Entry start+++++++++++++++++++")
-	logs.Info("entry request params:%v", string(body))
-	logs.Info("++++++++++++++++++Entry end+++++++++++++++++++")
+func (h *EntryHandler) Entry(ctx context.Context, session *nets.Session, body []byte) (any, error) {
+	log := logs.WithTrace(trace.FromContext(ctx))
+	log.Debug("entry request params:%v", string(body))
 	var req request.EntryReq
 	err := json.Unmarshal(body, &req)
 	if err != nil {
@@ -36,17 +40,29 @@ func (h *EntryHandler) Entry(session *nets.Session, body []byte) (any, error) {
 	}
 
 	//校验token
-	uid, err := jwts.ParseToken(req.Token, config.Conf.Jwt.Secret)
+	uid, err := jwts.ParseToken(req.Token, config.Conf().Jwt.Secret)
 	if err != nil {
-		logs.Error("parse token err :%v", err)
+		log.Error("parse token err :%v", err)
 		return common.Failed(biz.TokenInfoError), nil
 	}
 	//根据uid，取mongo查询用户，没有则创建、
-	user, err := h.userService.FindAndSaveUserByUid(context.TODO(), uid, req.UserInfo)
+	user, err := h.userService.FindAndSaveUserByUid(ctx, uid, req.UserInfo)
 	if err != nil {
 		return common.Failed(biz.SqlError), nil
 	}
 	session.Uid = uid
+	//联盟公告按session中的联盟筛选用户
+	unionIds := make([]int64, 0, len(user.UnionInfo))
+	for _, v := range user.UnionInfo {
+		unionIds = append(unionIds, v.UnionID)
+	}
+	session.Put("unionIds", unionIds)
+	//断线重连 恢复房间号和房间所在节点的路由，客户端收到roomID之后发送UserReconnectNotify
+	if len(user.RoomID) > 0 && len(user.GameServerId) > 0 {
+		session.Put("roomId", user.RoomID)
+		session.Put(remote.ServerKey("game"), user.GameServerId)
+	}
+	h.broadcast.OnEntry(uid)
 	return common.Successed(map[string]any{
 		"userInfo": user,
 		"config":   game.Conf.GetFrontGameConfig(),
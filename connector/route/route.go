@@ -1,6 +1,7 @@
 package route
 
 import (
+	"connector/broadcast"
 	"connector/handler"
 	"core/repo"
 	"framework/nets"
@@ -9,9 +10,9 @@ import (
 type Route struct {
 }
 
-func RegisterHandler(r *repo.Manager) nets.LogicHandler {
+func RegisterHandler(r *repo.Manager, scheduler *broadcast.Scheduler) nets.LogicHandler {
 	handles := make(nets.LogicHandler)
-	entryHandle := handler.NewEntryHandler(r)
+	entryHandle := handler.NewEntryHandler(r, scheduler)
 	handles["entryHandler.entry"] = entryHandle.Entry
 
 	return handles
@@ -0,0 +1,42 @@
+package dao
+
+import (
+	"context"
+	"core/models/entity"
+	"core/repo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type BroadcastDao struct {
+	repo *repo.Manager
+}
+
+func NewBroadcastDao(m *repo.Manager) *BroadcastDao {
+	return &BroadcastDao{
+		repo: m,
+	}
+}
+
+func (d *BroadcastDao) Insert(ctx context.Context, b *entity.Broadcast) error {
+	db := d.repo.Mongo.Db.Collection("broadcast")
+	_, err := db.InsertOne(ctx, b)
+	return err
+}
+
+// FindActive 查询now时刻生效的公告
+func (d *BroadcastDao) FindActive(ctx context.Context, now int64) ([]*entity.Broadcast, error) {
+	db := d.repo.Mongo.Db.Collection("broadcast")
+	cursor, err := db.Find(ctx, bson.M{
+		"startTime": bson.M{"$lte": now},
+		"endTime":   bson.M{"$gt": now},
+	}, options.Find().SetSort(bson.M{"startTime": 1}))
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*entity.Broadcast, 0)
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
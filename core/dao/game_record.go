@@ -0,0 +1,23 @@
+package dao
+
+import (
+	"context"
+	"core/models/entity"
+	"core/repo"
+)
+
+type GameRecordDao struct {
+	repo *repo.Manager
+}
+
+func (d *GameRecordDao) Insert(ctx context.Context, record *entity.GameRecord) error {
+	db := d.repo.Mongo.Db.Collection("gameRecord")
+	_, err := db.InsertOne(ctx, record)
+	return err
+}
+
+func NewGameRecordDao(m *repo.Manager) *GameRecordDao {
+	return &GameRecordDao{
+		repo: m,
+	}
+}
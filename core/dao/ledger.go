@@ -0,0 +1,127 @@
+package dao
+
+import (
+	"context"
+	"core/models/entity"
+	"core/repo"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrDuplicateLedger  = errors.New("duplicate idempotency key")
+	ErrBalanceNotEnough = errors.New("balance not enough")
+)
+
+type LedgerDao struct {
+	repo *repo.Manager
+}
+
+// Apply 在一个事务中修改余额并写入流水 全部成功或者全部失败，成功之后每条流水的Balance为变化之后的余额
+// checkEnough为true时余额不够扣除返回ErrBalanceNotEnough
+func (d *LedgerDao) Apply(ctx context.Context, entries []*entity.Ledger, checkEnough bool) error {
+	session, err := d.repo.Mongo.Cli.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (any, error) {
+		db := d.repo.Mongo.Db.Collection("ledger")
+		for _, e := range entries {
+			balance, err := d.incBalance(sc, e, checkEnough)
+			if err != nil {
+				return nil, err
+			}
+			e.Balance = balance
+			if _, err := db.InsertOne(sc, e); err != nil {
+				if mongo.IsDuplicateKeyError(err) {
+					return nil, ErrDuplicateLedger
+				}
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	return err
+}
+
+func (d *LedgerDao) incBalance(ctx context.Context, e *entity.Ledger, checkEnough bool) (int64, error) {
+	db := d.repo.Mongo.Db.Collection("user")
+	filter := bson.M{
+		"uid": e.Uid,
+	}
+	field := "gold"
+	if e.Currency == entity.CurrencyUnionScore {
+		union := bson.M{"unionID": e.UnionID}
+		if checkEnough && e.Delta < 0 {
+			union["score"] = bson.M{"$gte": -e.Delta}
+		}
+		filter["unionInfo"] = bson.M{"$elemMatch": union}
+		field = "unionInfo.$.score"
+	} else if checkEnough && e.Delta < 0 {
+		filter["gold"] = bson.M{"$gte": -e.Delta}
+	}
+	user := new(entity.User)
+	err := db.FindOneAndUpdate(ctx, filter, bson.M{
+		"$inc": bson.M{
+			field: e.Delta,
+		},
+	}, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			if checkEnough && e.Delta < 0 {
+				return 0, ErrBalanceNotEnough
+			}
+			return 0, fmt.Errorf("user %s not found,currency=%s,unionID=%d", e.Uid, e.Currency, e.UnionID)
+		}
+		return 0, err
+	}
+	if e.Currency != entity.CurrencyUnionScore {
+		return user.Gold, nil
+	}
+	for _, v := range user.UnionInfo {
+		if v.UnionID == e.UnionID {
+			return int64(v.Score), nil
+		}
+	}
+	return 0, nil
+}
+
+// FindByKey 按幂等key查询流水 不存在返回nil
+func (d *LedgerDao) FindByKey(ctx context.Context, key string) (*entity.Ledger, error) {
+	db := d.repo.Mongo.Db.Collection("ledger")
+	var ledger entity.Ledger
+	err := db.FindOne(ctx, bson.M{"_id": key}).Decode(&ledger)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ledger, nil
+}
+
+// FindByUid 用户最近的流水 按时间倒序
+func (d *LedgerDao) FindByUid(ctx context.Context, uid string, limit int64) ([]*entity.Ledger, error) {
+	db := d.repo.Mongo.Db.Collection("ledger")
+	cursor, err := db.Find(ctx, bson.M{
+		"uid": uid,
+	}, options.Find().SetSort(bson.D{{Key: "createTime", Value: -1}}).SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	ledgers := make([]*entity.Ledger, 0)
+	if err := cursor.All(ctx, &ledgers); err != nil {
+		return nil, err
+	}
+	return ledgers, nil
+}
+
+func NewLedgerDao(m *repo.Manager) *LedgerDao {
+	return &LedgerDao{
+		repo: m,
+	}
+}
@@ -0,0 +1,57 @@
+package dao
+
+import (
+	"context"
+	"core/repo"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// 房间快照 每个game节点一个hash field为房间号
+const (
+	RoomSnapshotRedisKey = "RoomSnapshot"
+	roomSnapshotExpire   = 24 * time.Hour
+)
+
+type RoomDao struct {
+	repo *repo.Manager
+}
+
+func roomSnapshotKey(serverId string) string {
+	return fmt.Sprintf("%s:%s:%s", Prefix, RoomSnapshotRedisKey, serverId)
+}
+
+func (d *RoomDao) SaveSnapshot(ctx context.Context, serverId string, roomId string, data []byte) error {
+	key := roomSnapshotKey(serverId)
+	cli := d.repo.Redis.Client()
+	if err := cli.HSet(ctx, key, roomId, data).Err(); err != nil {
+		return err
+	}
+	return cli.Expire(ctx, key, roomSnapshotExpire).Err()
+}
+
+func (d *RoomDao) DeleteSnapshot(ctx context.Context, serverId string, roomId string) error {
+	return d.repo.Redis.Client().HDel(ctx, roomSnapshotKey(serverId), roomId).Err()
+}
+
+// FindSnapshot 不存在返回nil
+func (d *RoomDao) FindSnapshot(ctx context.Context, serverId string, roomId string) ([]byte, error) {
+	data, err := d.repo.Redis.Client().HGet(ctx, roomSnapshotKey(serverId), roomId).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// FindSnapshots roomId -> 快照
+func (d *RoomDao) FindSnapshots(ctx context.Context, serverId string) (map[string]string, error) {
+	return d.repo.Redis.Client().HGetAll(ctx, roomSnapshotKey(serverId)).Result()
+}
+
+func NewRoomDao(m *repo.Manager) *RoomDao {
+	return &RoomDao{
+		repo: m,
+	}
+}
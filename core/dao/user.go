@@ -15,7 +15,7 @@ type UserDao struct {
 func (d *UserDao) FindUserByUid(ctx context.Context, uid string) (*entity.User, error) {
 	db := d.repo.Mongo.Db.Collection("user")
 	singleResult := db.FindOne(ctx, bson.D{
-		{"uid", uid},
+		{Key: "uid", Value: uid},
 	})
 	user := new(entity.User)
 	err := singleResult.Decode(user)
@@ -47,6 +47,36 @@ func (d *UserDao) UpdateUserAddressByUid(ctx context.Context, user *entity.User)
 	return err
 }
 
+// UpdateUserRoom 进入房间时记录房间号、所在的connector和房间所在的game节点，离开房间时roomId为空
+func (d *UserDao) UpdateUserRoom(ctx context.Context, uid string, roomId string, frontendId string, gameServerId string) error {
+	db := d.repo.Mongo.Db.Collection("user")
+	_, err := db.UpdateOne(ctx, bson.M{
+		"uid": uid,
+	}, bson.M{
+		"$set": bson.M{
+			"roomID":       roomId,
+			"frontendId":   frontendId,
+			"gameServerId": gameServerId,
+		},
+	})
+	return err
+}
+
+// ClearUserRoom 房间已经不存在 只有记录的还是这个房间时才清除
+func (d *UserDao) ClearUserRoom(ctx context.Context, uid string, roomId string) error {
+	db := d.repo.Mongo.Db.Collection("user")
+	_, err := db.UpdateOne(ctx, bson.M{
+		"uid":    uid,
+		"roomID": roomId,
+	}, bson.M{
+		"$set": bson.M{
+			"roomID":       "",
+			"gameServerId": "",
+		},
+	})
+	return err
+}
+
 func NewUserDao(m *repo.Manager) *UserDao {
 	return &UserDao{
 		repo: m,
@@ -0,0 +1,119 @@
+package dao
+
+import (
+	"bytes"
+	"context"
+	"core/models/entity"
+	"core/repo"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+// 语音文件的GridFS bucket 集合为voice.files和voice.chunks
+const voiceBucket = "voice"
+
+type VoiceDao struct {
+	repo *repo.Manager
+}
+
+func NewVoiceDao(m *repo.Manager) *VoiceDao {
+	return &VoiceDao{
+		repo: m,
+	}
+}
+
+func (d *VoiceDao) bucket(ctx context.Context) (*gridfs.Bucket, error) {
+	bucket, err := gridfs.NewBucket(d.repo.Mongo.Db, options.GridFSBucket().SetName(voiceBucket))
+	if err != nil {
+		return nil, err
+	}
+	//上传下载的接口没有ctx参数 使用ctx的超时时间
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = bucket.SetWriteDeadline(deadline)
+		_ = bucket.SetReadDeadline(deadline)
+	}
+	return bucket, nil
+}
+
+// Save 保存音频 返回文件id
+func (d *VoiceDao) Save(ctx context.Context, meta *entity.VoiceMeta, data []byte) (string, error) {
+	bucket, err := d.bucket(ctx)
+	if err != nil {
+		return "", err
+	}
+	id, err := bucket.UploadFromStream(meta.Uid, bytes.NewReader(data), options.GridFSUpload().SetMetadata(meta))
+	if err != nil {
+		return "", err
+	}
+	return id.Hex(), nil
+}
+
+// FindMeta 只查询元数据 不存在返回nil
+func (d *VoiceDao) FindMeta(ctx context.Context, id string) (*entity.VoiceMeta, error) {
+	fileId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, nil
+	}
+	var file struct {
+		Metadata entity.VoiceMeta `bson:"metadata"`
+	}
+	err = d.repo.Mongo.Db.Collection(voiceBucket+".files").FindOne(ctx, bson.M{"_id": fileId}).Decode(&file)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &file.Metadata, nil
+}
+
+// Find 查询音频 不存在返回nil
+func (d *VoiceDao) Find(ctx context.Context, id string) ([]byte, *entity.VoiceMeta, error) {
+	meta, err := d.FindMeta(ctx, id)
+	if meta == nil || err != nil {
+		return nil, nil, err
+	}
+	bucket, err := d.bucket(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	fileId, _ := primitive.ObjectIDFromHex(id)
+	var buf bytes.Buffer
+	if _, err := bucket.DownloadToStream(fileId, &buf); err != nil {
+		return nil, nil, err
+	}
+	return buf.Bytes(), meta, nil
+}
+
+// DeleteExpired 删除过期的音频 GridFS的chunks没有时间字段 不能用TTL索引，定时删除
+func (d *VoiceDao) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	bucket, err := d.bucket(ctx)
+	if err != nil {
+		return 0, err
+	}
+	cursor, err := bucket.FindContext(ctx, bson.M{
+		"metadata.expireAt": bson.M{"$lte": now.UnixMilli()},
+	})
+	if err != nil {
+		return 0, err
+	}
+	var files []struct {
+		Id primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &files); err != nil {
+		return 0, err
+	}
+	deleted := 0
+	for _, v := range files {
+		if err := bucket.DeleteContext(ctx, v.Id); err != nil && !errors.Is(err, gridfs.ErrFileNotFound) {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
@@ -0,0 +1,22 @@
+package entity
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Broadcast 跑马灯公告 在生效时间内按间隔循环推送
+type Broadcast struct {
+	Id         primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Content    string             `bson:"content" json:"content"`       // 公告内容
+	StartTime  int64              `bson:"startTime" json:"startTime"`   // 开始时间 毫秒
+	EndTime    int64              `bson:"endTime" json:"endTime"`       // 结束时间 毫秒
+	Interval   int                `bson:"interval" json:"interval"`     // 重复间隔 秒 0只推送一次
+	Target     int                `bson:"target" json:"target"`         // 推送对象
+	UnionID    int64              `bson:"unionID" json:"unionID"`       // Target为联盟时的联盟ID
+	GameType   int                `bson:"gameType" json:"gameType"`     // Target为游戏类型时的游戏类型
+	CreateTime int64              `bson:"createTime" json:"createTime"` // 创建时间
+}
+
+const (
+	BroadcastTargetAll      = iota // 所有在线用户
+	BroadcastTargetUnion           // 指定联盟成员
+	BroadcastTargetGameType        // 正在玩指定游戏的用户
+)
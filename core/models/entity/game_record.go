@@ -0,0 +1,21 @@
+package entity
+
+// GameRecord 房间局数打完之后的总结算 每个房间一条
+type GameRecord struct {
+	RoomID     string              `bson:"roomID" json:"roomID"`         // 房间ID
+	UnionID    int64               `bson:"unionID" json:"unionID"`       // 联盟ID
+	GameType   int                 `bson:"gameType" json:"gameType"`     // 游戏类型
+	Bureau     int                 `bson:"bureau" json:"bureau"`         // 打完的局数
+	Players    []*GameRecordPlayer `bson:"players" json:"players"`       // 参加过的玩家
+	CreateTime int64               `bson:"createTime" json:"createTime"` // 结束时间 毫秒
+}
+
+type GameRecordPlayer struct {
+	Uid       string `bson:"uid" json:"uid"`
+	Nickname  string `bson:"nickname" json:"nickname"`
+	Avatar    string `bson:"avatar" json:"avatar"`
+	ChairID   int    `bson:"chairID" json:"chairID"`
+	Scores    []int  `bson:"scores" json:"scores"`       // 每一局的得分 没有参加的局为0
+	Total     int    `bson:"total" json:"total"`         // 总得分
+	BigWinner bool   `bson:"bigWinner" json:"bigWinner"` // 大赢家
+}
@@ -0,0 +1,30 @@
+package entity
+
+// Ledger 余额变化的流水 每次金币或者联盟积分变化一条，幂等key作为_id保证同一个变化只执行一次
+type Ledger struct {
+	IdempotencyKey string `bson:"_id" json:"idempotencyKey"`        // 幂等key
+	Uid            string `bson:"uid" json:"uid"`                   // 用户唯一ID
+	Currency       string `bson:"currency" json:"currency"`         // 币种 CurrencyGold CurrencyUnionScore
+	UnionID        int64  `bson:"unionID,omitempty" json:"unionID"` // 联盟积分所属的联盟
+	Delta          int64  `bson:"delta" json:"delta"`               // 变化 减少为负数
+	Balance        int64  `bson:"balance" json:"balance"`           // 变化之后的余额
+	Reason         string `bson:"reason" json:"reason"`             // 变化原因
+	RefID          string `bson:"refID,omitempty" json:"refID"`     // 关联的ID 例如房间号
+	CreateTime     int64  `bson:"createTime" json:"createTime"`     // 毫秒
+}
+
+// 币种
+const (
+	CurrencyGold       = "gold"       // entity.User.Gold
+	CurrencyUnionScore = "unionScore" // entity.UnionInfo.Score
+)
+
+// 余额变化的原因
+const (
+	ReasonRoomFee        = "roomFee"        // 房费以及退还
+	ReasonSettlement     = "settlement"     // 游戏结算
+	ReasonSettlementVoid = "settlementVoid" // 中途解散不结算 退回已经结算的输赢
+	ReasonRecharge       = "recharge"       // 充值
+	ReasonGift           = "gift"           // 赠送
+	ReasonAdmin          = "admin"          // 后台修改
+)
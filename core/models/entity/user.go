@@ -9,6 +9,7 @@ type User struct {
 	Location         string             `bson:"location" json:"location"`                 // 地理位置信息，国家省市街道
 	FrontendId       string             `bson:"frontendId" json:"frontendId"`             // 前端服务器ID
 	RoomID           string             `bson:"roomID" json:"roomID"`                     // 房间ID
+	GameServerId     string             `bson:"gameServerId" json:"-"`                    // 房间所在的game节点 断线重连时恢复路由
 	IsAgent          bool               `bson:"isAgent" json:"isAgent"`                   // 是否是代理
 	RealName         string             `bson:"realName" json:"realName"`                 // 实名认证信息
 	MobilePhone      string             `bson:"mobilePhone" json:"mobilePhone"`           // 绑定的手机
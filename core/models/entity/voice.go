@@ -0,0 +1,9 @@
+package entity
+
+// VoiceMeta 语音消息 音频保存在GridFS中，元数据保存在文件的metadata中
+type VoiceMeta struct {
+	Uid      string `bson:"uid" json:"uid"`           // 发送的用户
+	RoomID   string `bson:"roomID" json:"roomID"`     // 房间ID
+	Duration int    `bson:"duration" json:"duration"` // 时长 毫秒
+	ExpireAt int64  `bson:"expireAt" json:"expireAt"` // 过期时间 毫秒 过期之后删除
+}
@@ -0,0 +1,11 @@
+package requests
+
+type BroadcastReq struct {
+	Content   string `json:"content"`
+	StartTime int64  `json:"startTime"` // 毫秒 不传为当前时间
+	EndTime   int64  `json:"endTime"`   // 毫秒
+	Interval  int    `json:"interval"`  // 秒
+	Target    int    `json:"target"`
+	UnionID   int64  `json:"unionID"`
+	GameType  int    `json:"gameType"`
+}
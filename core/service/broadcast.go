@@ -0,0 +1,70 @@
+package service
+
+import (
+	"common/biz"
+	"common/logs"
+	"context"
+	"core/dao"
+	"core/models/entity"
+	"core/models/requests"
+	"core/repo"
+	"framework/msError"
+	"time"
+)
+
+type BroadcastService struct {
+	broadcastDao *dao.BroadcastDao
+}
+
+func (s *BroadcastService) Create(ctx context.Context, req requests.BroadcastReq) (*entity.Broadcast, *msError.Error) {
+	now := time.Now().UnixMilli()
+	if req.StartTime == 0 {
+		req.StartTime = now
+	}
+	if len(req.Content) == 0 || req.EndTime <= req.StartTime || req.EndTime <= now || req.Interval < 0 {
+		return nil, biz.RequestDataError
+	}
+	switch req.Target {
+	case entity.BroadcastTargetAll:
+	case entity.BroadcastTargetUnion:
+		if req.UnionID == 0 {
+			return nil, biz.RequestDataError
+		}
+	case entity.BroadcastTargetGameType:
+		if req.GameType == 0 {
+			return nil, biz.RequestDataError
+		}
+	default:
+		return nil, biz.RequestDataError
+	}
+	b := &entity.Broadcast{
+		Content:    req.Content,
+		StartTime:  req.StartTime,
+		EndTime:    req.EndTime,
+		Interval:   req.Interval,
+		Target:     req.Target,
+		UnionID:    req.UnionID,
+		GameType:   req.GameType,
+		CreateTime: now,
+	}
+	if err := s.broadcastDao.Insert(ctx, b); err != nil {
+		logs.Error("[BroadcastService] Create insert err:%v", err)
+		return nil, biz.SqlError
+	}
+	return b, nil
+}
+
+func (s *BroadcastService) FindActive(ctx context.Context) ([]*entity.Broadcast, *msError.Error) {
+	list, err := s.broadcastDao.FindActive(ctx, time.Now().UnixMilli())
+	if err != nil {
+		logs.Error("[BroadcastService] FindActive err:%v", err)
+		return nil, biz.SqlError
+	}
+	return list, nil
+}
+
+func NewBroadcastService(r *repo.Manager) *BroadcastService {
+	return &BroadcastService{
+		broadcastDao: dao.NewBroadcastDao(r),
+	}
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"common/biz"
+	"common/logs"
+	"context"
+	"core/dao"
+	"core/models/entity"
+	"core/repo"
+	"framework/msError"
+)
+
+// GameRecordService 房间的总结算记录
+type GameRecordService struct {
+	gameRecordDao *dao.GameRecordDao
+}
+
+func (s *GameRecordService) Save(ctx context.Context, record *entity.GameRecord) *msError.Error {
+	if err := s.gameRecordDao.Insert(ctx, record); err != nil {
+		logs.With(logs.KeyRoomId, record.RoomID).Error("[GameRecordService] Save err:%v", err)
+		return biz.SqlError
+	}
+	return nil
+}
+
+func NewGameRecordService(r *repo.Manager) *GameRecordService {
+	return &GameRecordService{
+		gameRecordDao: dao.NewGameRecordDao(r),
+	}
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"common/biz"
+	"common/logs"
+	"context"
+	"core/dao"
+	"core/models/entity"
+	"core/repo"
+	"errors"
+	"framework/msError"
+	"time"
+)
+
+// LedgerService 所有金币和联盟积分的变化都通过Change 每次变化记录一条流水
+type LedgerService struct {
+	ledgerDao ledgerDao
+}
+
+// ledgerDao 由dao.LedgerDao实现 测试中替换为内存实现
+type ledgerDao interface {
+	Apply(ctx context.Context, entries []*entity.Ledger, checkEnough bool) error
+	FindByKey(ctx context.Context, key string) (*entity.Ledger, error)
+	FindByUid(ctx context.Context, uid string, limit int64) ([]*entity.Ledger, error)
+}
+
+// Change 在一个事务中执行entries中的变化 全部成功或者全部失败，成功之后entry.Balance为变化之后的余额
+// 幂等key重复说明已经执行过(例如超时重试) 返回成功，entry.Balance为当时记录的余额
+// 同一个key记录的用户或者变化不一致返回biz.DuplicateBalanceChange
+// checkEnough为true时余额不够扣除返回biz.NotEnoughGold或者biz.NotEnoughScore
+func (s *LedgerService) Change(ctx context.Context, entries []*entity.Ledger, checkEnough bool) *msError.Error {
+	if len(entries) == 0 {
+		return nil
+	}
+	now := time.Now().UnixMilli()
+	for _, e := range entries {
+		if len(e.IdempotencyKey) == 0 || len(e.Uid) == 0 || len(e.Reason) == 0 || e.Delta == 0 {
+			return biz.RequestDataError
+		}
+		if e.Currency != entity.CurrencyGold && e.Currency != entity.CurrencyUnionScore {
+			return biz.RequestDataError
+		}
+		e.CreateTime = now
+	}
+	err := s.ledgerDao.Apply(ctx, entries, checkEnough)
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, dao.ErrDuplicateLedger) {
+		return s.applied(ctx, entries)
+	}
+	if errors.Is(err, dao.ErrBalanceNotEnough) {
+		if entries[0].Currency == entity.CurrencyUnionScore {
+			return biz.NotEnoughScore
+		}
+		return biz.NotEnoughGold
+	}
+	logs.Error("[LedgerService] Change err:%v,key=%s", err, entries[0].IdempotencyKey)
+	return biz.SqlError
+}
+
+// applied 幂等key重复时读取已经记录的流水 同一个事务中的流水要么都存在要么都不存在
+func (s *LedgerService) applied(ctx context.Context, entries []*entity.Ledger) *msError.Error {
+	for _, e := range entries {
+		existing, err := s.ledgerDao.FindByKey(ctx, e.IdempotencyKey)
+		if err != nil {
+			logs.Error("[LedgerService] Change find duplicate err:%v,key=%s", err, e.IdempotencyKey)
+			return biz.SqlError
+		}
+		if existing == nil || existing.Uid != e.Uid || existing.Currency != e.Currency || existing.Delta != e.Delta {
+			logs.Error("[LedgerService] Change duplicate key conflict,key=%s", e.IdempotencyKey)
+			return biz.DuplicateBalanceChange
+		}
+		e.Balance = existing.Balance
+		e.CreateTime = existing.CreateTime
+	}
+	logs.Warn("[LedgerService] Change already applied,key=%s", entries[0].IdempotencyKey)
+	return nil
+}
+
+// FindByUid 用户最近的流水 对账使用
+func (s *LedgerService) FindByUid(ctx context.Context, uid string, limit int64) ([]*entity.Ledger, *msError.Error) {
+	ledgers, err := s.ledgerDao.FindByUid(ctx, uid, limit)
+	if err != nil {
+		logs.Error("[LedgerService] FindByUid err:%v,uid=%s", err, uid)
+		return nil, biz.SqlError
+	}
+	return ledgers, nil
+}
+
+func NewLedgerService(r *repo.Manager) *LedgerService {
+	return &LedgerService{
+		ledgerDao: dao.NewLedgerDao(r),
+	}
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"common/biz"
+	"context"
+	"core/dao"
+	"core/models/entity"
+	"testing"
+)
+
+// memLedgerDao 内存中的流水 Apply和mongo事务一样全部成功或者全部失败
+type memLedgerDao struct {
+	balances map[string]int64
+	ledgers  map[string]*entity.Ledger
+}
+
+func newMemLedgerDao() *memLedgerDao {
+	return &memLedgerDao{
+		balances: make(map[string]int64),
+		ledgers:  make(map[string]*entity.Ledger),
+	}
+}
+
+func (d *memLedgerDao) Apply(ctx context.Context, entries []*entity.Ledger, checkEnough bool) error {
+	balances := make(map[string]int64)
+	for _, e := range entries {
+		if _, ok := d.ledgers[e.IdempotencyKey]; ok {
+			return dao.ErrDuplicateLedger
+		}
+		balance, ok := balances[e.Uid]
+		if !ok {
+			balance = d.balances[e.Uid]
+		}
+		balance += e.Delta
+		if checkEnough && e.Delta < 0 && balance < 0 {
+			return dao.ErrBalanceNotEnough
+		}
+		balances[e.Uid] = balance
+		e.Balance = balance
+	}
+	for uid, balance := range balances {
+		d.balances[uid] = balance
+	}
+	for _, e := range entries {
+		stored := *e
+		d.ledgers[e.IdempotencyKey] = &stored
+	}
+	return nil
+}
+
+func (d *memLedgerDao) FindByKey(ctx context.Context, key string) (*entity.Ledger, error) {
+	return d.ledgers[key], nil
+}
+
+func (d *memLedgerDao) FindByUid(ctx context.Context, uid string, limit int64) ([]*entity.Ledger, error) {
+	return nil, nil
+}
+
+func goldEntry(key string, uid string, delta int64) *entity.Ledger {
+	return &entity.Ledger{
+		IdempotencyKey: key,
+		Uid:            uid,
+		Currency:       entity.CurrencyGold,
+		Delta:          delta,
+		Reason:         entity.ReasonSettlement,
+	}
+}
+
+func TestLedgerChangeReplay(t *testing.T) {
+	d := newMemLedgerDao()
+	d.balances["u1"] = 100
+	s := &LedgerService{ledgerDao: d}
+	ctx := context.Background()
+	if err := s.Change(ctx, []*entity.Ledger{goldEntry("k1", "u1", -10)}, true); err != nil {
+		t.Fatal(err)
+	}
+	//其他变化之后重试 返回当时记录的余额，不重复扣除
+	if err := s.Change(ctx, []*entity.Ledger{goldEntry("k2", "u1", -20)}, true); err != nil {
+		t.Fatal(err)
+	}
+	replay := goldEntry("k1", "u1", -10)
+	if err := s.Change(ctx, []*entity.Ledger{replay}, true); err != nil {
+		t.Fatalf("replay should succeed, got %v", err)
+	}
+	if replay.Balance != 90 {
+		t.Fatalf("replay balance %d, want 90", replay.Balance)
+	}
+	if d.balances["u1"] != 70 {
+		t.Fatalf("balance %d, want 70", d.balances["u1"])
+	}
+}
+
+func TestLedgerChangeConflict(t *testing.T) {
+	cases := []struct {
+		name  string
+		entry *entity.Ledger
+	}{
+		{"other uid", goldEntry("k1", "u2", -10)},
+		{"other delta", goldEntry("k1", "u1", -20)},
+		{"other currency", &entity.Ledger{IdempotencyKey: "k1", Uid: "u1", Currency: entity.CurrencyUnionScore, Delta: -10, Reason: entity.ReasonSettlement}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			d := newMemLedgerDao()
+			d.balances["u1"] = 100
+			s := &LedgerService{ledgerDao: d}
+			ctx := context.Background()
+			if err := s.Change(ctx, []*entity.Ledger{goldEntry("k1", "u1", -10)}, false); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Change(ctx, []*entity.Ledger{c.entry}, false); err != biz.DuplicateBalanceChange {
+				t.Fatalf("conflicting replay should fail with DuplicateBalanceChange, got %v", err)
+			}
+		})
+	}
+}
+
+func TestLedgerChangeMultiEntry(t *testing.T) {
+	d := newMemLedgerDao()
+	d.balances["u1"] = 100
+	d.balances["u2"] = 5
+	s := &LedgerService{ledgerDao: d}
+	ctx := context.Background()
+	//第二条余额不够 第一条也不生效
+	entries := []*entity.Ledger{goldEntry("s:u1", "u1", 10), goldEntry("s:u2", "u2", -10)}
+	if err := s.Change(ctx, entries, true); err != biz.NotEnoughGold {
+		t.Fatalf("want NotEnoughGold, got %v", err)
+	}
+	if d.balances["u1"] != 100 || len(d.ledgers) != 0 {
+		t.Fatalf("failed transaction should change nothing, balances=%v", d.balances)
+	}
+	entries = []*entity.Ledger{goldEntry("s:u1", "u1", 10), goldEntry("s:u2", "u2", -5)}
+	if err := s.Change(ctx, entries, true); err != nil {
+		t.Fatal(err)
+	}
+	//整个事务重试 每条都返回记录的余额
+	replay := []*entity.Ledger{goldEntry("s:u1", "u1", 10), goldEntry("s:u2", "u2", -5)}
+	if err := s.Change(ctx, replay, true); err != nil {
+		t.Fatalf("replay should succeed, got %v", err)
+	}
+	if replay[0].Balance != 110 || replay[1].Balance != 0 {
+		t.Fatalf("replay balances %d,%d, want 110,0", replay[0].Balance, replay[1].Balance)
+	}
+	//同一个事务只有部分流水存在 说明key被其他变化使用
+	partial := []*entity.Ledger{goldEntry("s:u1", "u1", 10), goldEntry("s:u3", "u3", -5)}
+	if err := s.Change(ctx, partial, false); err != biz.DuplicateBalanceChange {
+		t.Fatalf("partial replay should fail with DuplicateBalanceChange, got %v", err)
+	}
+}
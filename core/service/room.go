@@ -0,0 +1,70 @@
+package service
+
+import (
+	"common/biz"
+	"common/logs"
+	"context"
+	"core/dao"
+	"core/repo"
+	"encoding/json"
+	"framework/msError"
+)
+
+// RoomService game节点的房间快照，drain和崩溃恢复使用
+type RoomService struct {
+	roomDao *dao.RoomDao
+}
+
+func (s *RoomService) SaveSnapshot(ctx context.Context, serverId string, roomId string, data []byte) *msError.Error {
+	if err := s.roomDao.SaveSnapshot(ctx, serverId, roomId, data); err != nil {
+		logs.With(logs.KeyRoomId, roomId).Error("[RoomService] SaveSnapshot err:%v", err)
+		return biz.SqlError
+	}
+	return nil
+}
+
+func (s *RoomService) DeleteSnapshot(ctx context.Context, serverId string, roomId string) *msError.Error {
+	if err := s.roomDao.DeleteSnapshot(ctx, serverId, roomId); err != nil {
+		logs.With(logs.KeyRoomId, roomId).Error("[RoomService] DeleteSnapshot err:%v", err)
+		return biz.SqlError
+	}
+	return nil
+}
+
+func (s *RoomService) FindSnapshots(ctx context.Context, serverId string) (map[string]string, *msError.Error) {
+	res, err := s.roomDao.FindSnapshots(ctx, serverId)
+	if err != nil {
+		logs.Error("[RoomService] FindSnapshots err:%v,serverId=%s", err, serverId)
+		return nil, biz.SqlError
+	}
+	return res, nil
+}
+
+// VoiceEnabled 房间规则是否开启了语音 从game节点保存的房间快照中读取
+// 只解析快照中gameRule.yuyin 房间不存在时返回RoomNotExist
+func (s *RoomService) VoiceEnabled(ctx context.Context, serverId string, roomId string) (bool, *msError.Error) {
+	data, err := s.roomDao.FindSnapshot(ctx, serverId, roomId)
+	if err != nil {
+		logs.With(logs.KeyRoomId, roomId).Error("[RoomService] VoiceEnabled err:%v", err)
+		return false, biz.SqlError
+	}
+	if data == nil {
+		return false, biz.RoomNotExist
+	}
+	var snapshot struct {
+		GameRule struct {
+			Yuyin bool `json:"yuyin"`
+		} `json:"gameRule"`
+	}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		logs.With(logs.KeyRoomId, roomId).Error("[RoomService] VoiceEnabled decode err:%v", err)
+		return false, biz.SqlError
+	}
+	return snapshot.GameRule.Yuyin, nil
+}
+
+func NewRoomService(r *repo.Manager) *RoomService {
+	return &RoomService{
+		roomDao: dao.NewRoomDao(r),
+	}
+}
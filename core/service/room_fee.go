@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"core/models/entity"
+	"core/repo"
+	"framework/msError"
+)
+
+// RoomFeeService 房费 从用户的金币中扣除，每个房间每个用户最多扣除和退还一次
+// 房间号会被重复使用 幂等key使用房间创建时生成的instanceId
+type RoomFeeService struct {
+	ledgerService *LedgerService
+}
+
+// Charge 扣除房费 返回扣除之后的金币
+func (s *RoomFeeService) Charge(ctx context.Context, uid string, roomId string, instanceId string, amount int64) (int64, *msError.Error) {
+	return s.change(ctx, "roomFee:"+instanceId+":"+uid, uid, roomId, -amount)
+}
+
+// Refund 退还房费 返回退还之后的金币
+func (s *RoomFeeService) Refund(ctx context.Context, uid string, roomId string, instanceId string, amount int64) (int64, *msError.Error) {
+	return s.change(ctx, "roomFeeRefund:"+instanceId+":"+uid, uid, roomId, amount)
+}
+
+func (s *RoomFeeService) change(ctx context.Context, key string, uid string, roomId string, delta int64) (int64, *msError.Error) {
+	entry := &entity.Ledger{
+		IdempotencyKey: key,
+		Uid:            uid,
+		Currency:       entity.CurrencyGold,
+		Delta:          delta,
+		Reason:         entity.ReasonRoomFee,
+		RefID:          roomId,
+	}
+	if err := s.ledgerService.Change(ctx, []*entity.Ledger{entry}, true); err != nil {
+		return 0, err
+	}
+	return entry.Balance, nil
+}
+
+func NewRoomFeeService(r *repo.Manager) *RoomFeeService {
+	return &RoomFeeService{
+		ledgerService: NewLedgerService(r),
+	}
+}
@@ -31,7 +31,7 @@ func (s *UserService) FindAndSaveUserByUid(ctx context.Context, uid string, info
 		//save
 		user = &entity.User{}
 		user.Uid = uid
-		user.Gold = int64(game.Conf.GameConfig["startGold"]["value"].(float64))
+		user.Gold = game.Conf.Settings().StartGold
 		user.Avatar = utils.Default(info.Avatar, "Common/head_icon_default")
 		user.Nickname = utils.Default(info.Nickname, fmt.Sprintf("%s%s", "码神", uid))
 		user.Sex = info.Sex //0 男 1 女
@@ -70,6 +70,24 @@ func (s *UserService) UpdateUserAddressByUid(uid string, req hall.UpdateUserAddr
 	return nil
 }
 
+func (s *UserService) UpdateUserRoom(ctx context.Context, uid string, roomId string, frontendId string, gameServerId string) *msError.Error {
+	err := s.userDao.UpdateUserRoom(ctx, uid, roomId, frontendId, gameServerId)
+	if err != nil {
+		logs.Error("[UserService] UpdateUserRoom err:%v,uid=%s", err, uid)
+		return biz.SqlError
+	}
+	return nil
+}
+
+func (s *UserService) ClearUserRoom(ctx context.Context, uid string, roomId string) *msError.Error {
+	err := s.userDao.ClearUserRoom(ctx, uid, roomId)
+	if err != nil {
+		logs.Error("[UserService] ClearUserRoom err:%v,uid=%s", err, uid)
+		return biz.SqlError
+	}
+	return nil
+}
+
 func NewUserService(r *repo.Manager) *UserService {
 	return &UserService{
 		userDao: dao.NewUserDao(r),
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"common/biz"
+	"common/logs"
+	"context"
+	"core/dao"
+	"core/models/entity"
+	"core/repo"
+	"framework/msError"
+	"time"
+)
+
+const (
+	VoiceMaxSize     = 256 * 1024     // 单条语音最大字节数
+	VoiceMaxDuration = 60 * 1000      // 单条语音最长时长 毫秒
+	voiceTTL         = 24 * time.Hour // 语音保存时间
+)
+
+type VoiceService struct {
+	voiceDao *dao.VoiceDao
+}
+
+// Upload 保存语音 返回获取语音的id
+func (s *VoiceService) Upload(ctx context.Context, uid string, roomId string, duration int, data []byte) (string, *msError.Error) {
+	if len(data) == 0 || duration <= 0 {
+		return "", biz.RequestDataError
+	}
+	if len(data) > VoiceMaxSize || duration > VoiceMaxDuration {
+		return "", biz.VoiceTooLarge
+	}
+	meta := &entity.VoiceMeta{
+		Uid:      uid,
+		RoomID:   roomId,
+		Duration: duration,
+		ExpireAt: time.Now().Add(voiceTTL).UnixMilli(),
+	}
+	id, err := s.voiceDao.Save(ctx, meta, data)
+	if err != nil {
+		logs.Error("[VoiceService] Upload err:%v,uid=%s", err, uid)
+		return "", biz.SqlError
+	}
+	return id, nil
+}
+
+func (s *VoiceService) Find(ctx context.Context, id string) ([]byte, *entity.VoiceMeta, *msError.Error) {
+	data, meta, err := s.voiceDao.Find(ctx, id)
+	if err != nil {
+		logs.Error("[VoiceService] Find err:%v,id=%s", err, id)
+		return nil, nil, biz.SqlError
+	}
+	if meta == nil || meta.ExpireAt <= time.Now().UnixMilli() {
+		return nil, nil, biz.VoiceNotExist
+	}
+	return data, meta, nil
+}
+
+// FindMeta 查询语音的发送人和房间 转发和获取语音时校验
+func (s *VoiceService) FindMeta(ctx context.Context, id string) (*entity.VoiceMeta, *msError.Error) {
+	meta, err := s.voiceDao.FindMeta(ctx, id)
+	if err != nil {
+		logs.Error("[VoiceService] FindMeta err:%v,id=%s", err, id)
+		return nil, biz.SqlError
+	}
+	if meta == nil || meta.ExpireAt <= time.Now().UnixMilli() {
+		return nil, biz.VoiceNotExist
+	}
+	return meta, nil
+}
+
+// DeleteExpired 删除过期的语音
+func (s *VoiceService) DeleteExpired(ctx context.Context) {
+	deleted, err := s.voiceDao.DeleteExpired(ctx, time.Now())
+	if err != nil {
+		logs.Error("[VoiceService] DeleteExpired err:%v", err)
+	}
+	if deleted > 0 {
+		logs.Info("delete expired voice count=%d", deleted)
+	}
+}
+
+func NewVoiceService(r *repo.Manager) *VoiceService {
+	return &VoiceService{
+		voiceDao: dao.NewVoiceDao(r),
+	}
+}
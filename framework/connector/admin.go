@@ -0,0 +1,167 @@
+package connector
+
+import (
+	"common"
+	"common/biz"
+	"common/logs"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"framework/game"
+	"framework/msError"
+	"framework/remote"
+	"net/http"
+	"strings"
+)
+
+// 运维管理接口 每个connector单独监听adminPort
+// GET  /admin/connections          所有连接
+// GET  /admin/connections/{uid}    按uid查询连接
+// POST /admin/kick                 {"uid":"","reason":""}
+// POST /admin/broadcast            {"uids":[],"data":{}} uids为空推送给所有人
+// GET  /admin/drain                当前connector以及节点的drain状态
+// POST /admin/drain                当前connector进入drain，客户端断开之后进程退出
+// POST /admin/drain/{serverId}     转发drain命令给节点(例如game节点)
+
+const systemPushRoute = "ServerMessagePush"
+
+type kickReq struct {
+	Uid    string `json:"uid"`
+	Reason string `json:"reason"`
+}
+
+type broadcastReq struct {
+	Uids []string        `json:"uids"`
+	Data json.RawMessage `json:"data"`
+}
+
+func (c *Connector) serveAdmin(conf *game.ConnectorConfig) {
+	if len(conf.AdminToken) == 0 {
+		logs.Error("connector admin token is empty,admin api disabled")
+		return
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /admin/connections", c.adminConnections)
+	mux.HandleFunc("GET /admin/connections/{uid}", c.adminFindByUid)
+	mux.HandleFunc("POST /admin/kick", c.adminKick)
+	mux.HandleFunc("POST /admin/broadcast", c.adminBroadcast)
+	mux.HandleFunc("GET /admin/drain", c.adminDrainState)
+	mux.HandleFunc("POST /admin/drain", c.adminDrain)
+	mux.HandleFunc("POST /admin/drain/{serverId}", c.adminDrainServer)
+	for pattern, handler := range c.adminHandlers {
+		mux.HandleFunc(pattern, handler)
+	}
+	//管理接口默认只监听本机 需要对外时显式配置adminHost
+	host := conf.AdminHost
+	if len(host) == 0 {
+		host = "127.0.0.1"
+	}
+	addr := fmt.Sprintf("%s:%d", host, conf.AdminPort)
+	logs.Info("connector admin api listen on %s", addr)
+	if err := http.ListenAndServe(addr, adminAuth(conf.AdminToken, mux)); err != nil {
+		logs.Error("connector admin api serve err:%v", err)
+	}
+}
+
+func adminAuth(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqToken := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(reqToken), []byte(token)) != 1 {
+			WriteResult(w, http.StatusUnauthorized, common.Failed(biz.PermissionNotEnough))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (c *Connector) adminConnections(w http.ResponseWriter, r *http.Request) {
+	WriteResult(w, http.StatusOK, common.Successed(c.wsManager.Connections()))
+}
+
+func (c *Connector) adminFindByUid(w http.ResponseWriter, r *http.Request) {
+	WriteResult(w, http.StatusOK, common.Successed(c.wsManager.FindByUid(r.PathValue("uid"))))
+}
+
+func (c *Connector) adminKick(w http.ResponseWriter, r *http.Request) {
+	var req kickReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Uid) == 0 {
+		WriteFail(w, biz.RequestDataError)
+		return
+	}
+	count, err := c.wsManager.Kick(req.Uid, req.Reason)
+	if err != nil {
+		logs.Error("admin kick err:%v,uid=%s", err, req.Uid)
+		WriteFail(w, biz.Fail)
+		return
+	}
+	logs.With(logs.KeyUid, req.Uid).Info("admin kick user,reason=%s,connections=%d", req.Reason, count)
+	WriteResult(w, http.StatusOK, common.Successed(map[string]any{
+		"count": count,
+	}))
+}
+
+func (c *Connector) adminBroadcast(w http.ResponseWriter, r *http.Request) {
+	var req broadcastReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Data) == 0 {
+		WriteFail(w, biz.RequestDataError)
+		return
+	}
+	count, err := c.wsManager.Broadcast(req.Uids, systemPushRoute, req.Data)
+	if err != nil {
+		logs.Error("admin broadcast err:%v", err)
+		WriteFail(w, biz.Fail)
+		return
+	}
+	logs.Info("admin broadcast,users=%d,connections=%d", len(req.Uids), count)
+	WriteResult(w, http.StatusOK, common.Successed(map[string]any{
+		"count": count,
+	}))
+}
+
+func (c *Connector) adminDrainState(w http.ResponseWriter, r *http.Request) {
+	WriteResult(w, http.StatusOK, common.Successed(map[string]any{
+		"draining":        c.wsManager.Draining(),
+		"drainingServers": c.wsManager.DrainingServers(),
+	}))
+}
+
+func (c *Connector) adminDrain(w http.ResponseWriter, r *http.Request) {
+	logs.Info("admin drain connector")
+	c.StartDrain()
+	WriteResult(w, http.StatusOK, common.Successed(nil))
+}
+
+func (c *Connector) adminDrainServer(w http.ResponseWriter, r *http.Request) {
+	serverId := r.PathValue("serverId")
+	if game.Conf.GetServer(serverId) == nil {
+		WriteFail(w, biz.RequestDataError)
+		return
+	}
+	data, err := remote.MsgEncode(&remote.Msg{
+		Src:  c.wsManager.ServerId,
+		Dst:  serverId,
+		Type: remote.DrainType,
+	})
+	if err == nil {
+		err = c.remoteCli.SendMsg(serverId, data)
+	}
+	if err != nil {
+		logs.Error("admin drain server err:%v,serverId=%s", err, serverId)
+		WriteFail(w, biz.Fail)
+		return
+	}
+	logs.Info("admin drain server %s", serverId)
+	WriteResult(w, http.StatusOK, common.Successed(nil))
+}
+
+// WriteFail 管理接口统一的失败响应
+func WriteFail(w http.ResponseWriter, err *msError.Error) {
+	WriteResult(w, http.StatusOK, common.Result{Code: err.Code, Msg: err.Err.Error()})
+}
+
+// WriteResult 管理接口统一的json响应
+func WriteResult(w http.ResponseWriter, status int, result common.Result) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(result)
+}
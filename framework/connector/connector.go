@@ -2,23 +2,31 @@ package connector
 
 import (
 	"common/logs"
+	"context"
 	"fmt"
 	"framework/game"
 	"framework/nets"
 	"framework/remote"
+	"net/http"
+	"sync"
 )
 
 type Connector struct {
-	isRunning bool
-	wsManager *nets.Manager
-	handles   nets.LogicHandler
-	remoteCli remote.Client
+	isRunning     bool
+	wsManager     *nets.Manager
+	handles       nets.LogicHandler
+	remoteCli     remote.Client
+	adminHandlers map[string]http.HandlerFunc
+	drainChan     chan struct{}
+	drainOnce     sync.Once
 }
 
 func Default() *Connector {
 	return &Connector{
-		wsManager: nets.NewManager(),
-		handles:   make(nets.LogicHandler),
+		wsManager:     nets.NewManager(),
+		handles:       make(nets.LogicHandler),
+		adminHandlers: make(map[string]http.HandlerFunc),
+		drainChan:     make(chan struct{}),
 		//handles: make(map[string]nets.HandleFunc),
 	}
 
@@ -26,7 +34,6 @@ func Default() *Connector {
 func (c *Connector) Run(ServerId string) {
 	if !c.isRunning {
 		//启动websocket和nats
-		c.wsManager = nets.NewManager()
 		c.wsManager.ConnectorHandlers = c.handles
 		//启动nats
 		c.remoteCli = remote.NewNatClient(ServerId, c.wsManager.RemoteReadChan)
@@ -52,9 +59,60 @@ func (c *Connector) Serve(serverId string) {
 		logs.Fatal("no connector config found")
 	}
 	addr := fmt.Sprintf("%s:%d", connectorConfig.Host, connectorConfig.ClientPort)
+	if connectorConfig.AdminPort > 0 {
+		go c.serveAdmin(connectorConfig)
+	}
+	game.Conf.OnGameConfigChange("", c.pushGameConfig)
 	c.isRunning = true
 	c.wsManager.Run(addr)
 }
 func (c *Connector) RegisterHandler(handles nets.LogicHandler) {
 	c.handles = handles
 }
+
+// pushGameConfig gameConfig修改之后 将客户端可见的配置推送给所有在线用户
+func (c *Connector) pushGameConfig(key string, value game.GameConfigValue) {
+	front, ok := value.FrontValue()
+	if !ok {
+		return
+	}
+	count, err := c.wsManager.Push(func(session *nets.Session) bool {
+		return true
+	}, systemPushRoute, map[string]any{
+		"config":     map[string]any{key: front},
+		"pushRouter": "UpdateGameConfigPush",
+	})
+	if err != nil {
+		logs.Error("push game config err:%v,key=%s", err, key)
+		return
+	}
+	logs.Info("push game config change,key=%s,connections=%d", key, count)
+}
+
+// RegisterAdminHandler 业务扩展的管理接口 需要在Run之前注册，同样经过token鉴权
+func (c *Connector) RegisterAdminHandler(pattern string, handler http.HandlerFunc) {
+	c.adminHandlers[pattern] = handler
+}
+
+// Push 推送给当前connector上满足filter的用户
+func (c *Connector) Push(filter func(*nets.Session) bool, route string, data any) (int, error) {
+	return c.wsManager.Push(filter, route, data)
+}
+
+// StartDrain 通知应用开始drain，信号和管理接口都通过这里触发
+func (c *Connector) StartDrain() {
+	c.drainOnce.Do(func() {
+		close(c.drainChan)
+	})
+}
+
+// Draining StartDrain之后关闭
+func (c *Connector) Draining() <-chan struct{} {
+	return c.drainChan
+}
+
+// Drain 不再接受新连接，通知客户端重连到其他connector，等待客户端断开或者ctx结束
+func (c *Connector) Drain(ctx context.Context) {
+	c.StartDrain()
+	c.wsManager.Drain(ctx)
+}
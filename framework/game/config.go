@@ -1,27 +1,36 @@
 package game
 
 import (
+	"common/config"
 	"common/logs"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"github.com/fsnotify/fsnotify"
-	"github.com/spf13/viper"
-	"io"
-	"log"
+	"io/fs"
 	"os"
-	"path"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 var Conf *Config
 
+const defaultDrainTimeout = 5 * time.Minute
+
 const (
 	gameConfig = "gameConfig.json"
 	servers    = "servers.json"
 )
 
+// Config gameConfig.json和servers.json 修改文件之后自动重新加载
+// 每次加载生成新的快照原子替换，读取方拿到的快照不会被修改，调用方也不要修改返回的数据
 type Config struct {
-	GameConfig  map[string]GameConfigValue `json:"gameConfig"`
-	ServersConf ServersConf                `json:"serversConf"`
+	gameConfig  atomic.Pointer[map[string]GameConfigValue]
+	settings    atomic.Pointer[Settings]
+	serversConf atomic.Pointer[ServersConf]
+	subLock     sync.RWMutex
+	subscribers map[string][]GameConfigChangeFunc
 }
 type ServersConf struct {
 	Nats       NatsConfig         `json:"nats"`
@@ -36,125 +45,202 @@ type ServersConfig struct {
 	HandleTimeOut    int    `json:"handleTimeOut"`
 	RPCTimeOut       int    `json:"rpcTimeOut"`
 	MaxRunRoutineNum int    `json:"maxRunRoutineNum"`
+	Queue            bool   `json:"queue"`        //无状态服务 同类型服务器共享队列组，由nats负载均衡
+	DrainTimeout     int    `json:"drainTimeout"` //drain时等待房间结束的时间 秒
 }
 
 type ConnectorConfig struct {
-	ID         string `json:"id"`
-	Host       string `json:"host"`
-	ClientPort int    `json:"clientPort"`
-	Frontend   bool   `json:"frontend"`
-	ServerType string `json:"serverType"`
+	ID           string `json:"id"`
+	Host         string `json:"host"`
+	ClientPort   int    `json:"clientPort"`
+	Frontend     bool   `json:"frontend"`
+	ServerType   string `json:"serverType"`
+	AdminPort    int    `json:"adminPort"`    //运维管理接口端口 0不开启
+	AdminHost    string `json:"adminHost"`    //管理接口监听地址 为空时只监听127.0.0.1
+	AdminToken   string `json:"adminToken"`   //管理接口鉴权 请求头Authorization: Bearer <token>
+	DrainTimeout int    `json:"drainTimeout"` //drain时等待客户端断开的时间 秒
 }
 type NatsConfig struct {
-	Url string `json:"url"`
+	Url              string `json:"url"`
+	ReconnectWait    int    `json:"reconnectWait"`    //重连间隔 秒
+	MaxReconnects    int    `json:"maxReconnects"`    //最大重连次数 0不限制
+	ReconnectBufSize int    `json:"reconnectBufSize"` //断线期间发送缓冲大小 字节
 }
 type GameConfigValue map[string]any
 
+// GameConfigChangeFunc 配置项变化回调 value为nil表示配置项被删除
+type GameConfigChangeFunc func(key string, value GameConfigValue)
+
+func newConfig() *Config {
+	c := &Config{
+		subscribers: make(map[string][]GameConfigChangeFunc),
+	}
+	c.gameConfig.Store(&map[string]GameConfigValue{})
+	c.settings.Store(&Settings{})
+	c.serversConf.Store(&ServersConf{TypeServer: map[string][]*ServersConfig{}})
+	return c
+}
+
+// InitConfig 通过config.Provider读取配置，开启配置中心时从etcd读取并按层级合并，否则读取本地目录configDir
 func InitConfig(configDir string) {
-	Conf = new(Config)
-	dir, err := os.ReadDir(configDir)
+	Conf = newConfig()
+	provider := config.NewProvider(configDir)
+	for _, name := range []string{gameConfig, servers} {
+		data, err := provider.Load(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			logs.Warn("%s not found", name)
+			continue
+		}
+		if err != nil {
+			logs.Fatal("load %s err:%v", name, err)
+		}
+		if err := Conf.load(name, data); err != nil {
+			logs.Fatal("load %s err:%v", name, err)
+		}
+	}
+	for _, name := range []string{gameConfig, servers} {
+		provider.Watch(name, func(data []byte) {
+			//修改之后的配置有错误 保留之前的配置
+			if err := Conf.load(name, data); err != nil {
+				logs.Error("reload %s err:%v", name, err)
+				return
+			}
+			logs.Info("reload %s success", name)
+		})
+	}
+}
+
+func readJSON(configFile string, v any) error {
+	data, err := os.ReadFile(configFile)
 	if err != nil {
-		logs.Fatal("read config dir err:%v", err)
+		return err
+	}
+	return parseJSON(configFile, data, v)
+}
+
+func parseJSON(name string, data []byte, v any) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("parse %s err:%v", name, err)
 	}
-	for _, v := range dir {
-		configFile := path.Join(configDir, v.Name())
-		if v.Name() == gameConfig {
-			readGameConfig(configFile)
+	return nil
+}
 
+func (c *Config) load(name string, data []byte) error {
+	switch name {
+	case gameConfig:
+		var gameConfig = make(map[string]GameConfigValue)
+		if err := parseJSON(name, data, &gameConfig); err != nil {
+			return err
 		}
-		if v.Name() == servers {
-			readServersConfig(configFile)
+		return c.SetGameConfig(gameConfig)
+	case servers:
+		var serversConf ServersConf
+		if err := parseJSON(name, data, &serversConf); err != nil {
+			return err
 		}
+		if err := ValidateServers(&serversConf); err != nil {
+			return err
+		}
+		c.SetServersConf(serversConf)
 	}
+	return nil
 }
-func readGameConfig(configFile string) {
-	//var gameConfig = make(map[string]GameConfigValue)
-	//v := viper.New()
-	//v.SetConfigFile(configFile)
-	//v.WatchConfig()
-	//v.OnConfigChange(func(e fsnotify.Event) {
-	//	log.Println("gameConfig配置文件被修改")
-	//	err := v.Unmarshal(&gameConfig)
-	//	if err != nil {
-	//		panic(fmt.Errorf("gameConfi配置文件被修改以后，报错，err:%v \n", err))
-	//	}
-	//	Conf.GameConfig = gameConfig
-	//
-	//})
-	//err := v.ReadInConfig()
-	//if err != nil {
-	//	panic(fmt.Errorf("gameConfi读取配置文件报错，err:%v \n", err))
-	//}
-	//if err := v.Unmarshal(&gameConfig); err != nil {
-	//	panic(fmt.Errorf("gameConfi Unmarshal data to Conf failed ，err:%v \n", err))
-	//}
-	//Conf.GameConfig = gameConfig
-	file, err := os.Open(configFile)
+
+// SetGameConfig 校验通过之后替换gameConfig快照，并通知发生变化的配置项的订阅者
+func (c *Config) SetGameConfig(gameConfig map[string]GameConfigValue) error {
+	settings, err := ParseSettings(gameConfig)
 	if err != nil {
-		panic(err)
+		return err
 	}
-	defer file.Close()
-	data, err := io.ReadAll(file)
-	if err != nil {
-		panic(err)
+	c.settings.Store(settings)
+	old := c.gameConfig.Swap(&gameConfig)
+	changed := make(map[string]GameConfigValue)
+	for k, v := range gameConfig {
+		if oldValue, ok := (*old)[k]; !ok || !reflect.DeepEqual(oldValue, v) {
+			changed[k] = v
+		}
 	}
-	var gameConfig = make(map[string]GameConfigValue)
-	err = json.Unmarshal(data, &gameConfig)
-	if err != nil {
-		panic(err)
-	}
-	Conf.GameConfig = gameConfig
-}
-func readServersConfig(configFile string) {
-	var serversConf ServersConf
-	v := viper.New()
-	v.SetConfigFile(configFile)
-	v.WatchConfig()
-	v.OnConfigChange(func(e fsnotify.Event) {
-		log.Println("serversConf配置文件被修改")
-		err := v.Unmarshal(&serversConf)
-		if err != nil {
-			panic(fmt.Errorf("serversConf配置文件被修改以后，报错，err:%v \n", err))
+	for k := range *old {
+		if _, ok := gameConfig[k]; !ok {
+			changed[k] = nil
 		}
-		Conf.ServersConf = serversConf
-		typeServerConfig()
-	})
-	err := v.ReadInConfig()
-	if err != nil {
-		panic(fmt.Errorf("读取serversConf配置文件报错，err:%v \n", err))
 	}
-	if err := v.Unmarshal(&serversConf); err != nil {
-		panic(fmt.Errorf("Unmarshal data to Conf failed ，err:%v \n", err))
+	for k, v := range changed {
+		c.notify(k, v)
 	}
-	Conf.ServersConf = serversConf
-	typeServerConfig()
+	return nil
 }
 
-func typeServerConfig() {
-	if len(Conf.ServersConf.Servers) > 0 {
-		if Conf.ServersConf.TypeServer == nil {
-			Conf.ServersConf.TypeServer = make(map[string][]*ServersConfig)
-		}
-		for _, v := range Conf.ServersConf.Servers {
-			if Conf.ServersConf.TypeServer[v.ServerType] == nil {
-				Conf.ServersConf.TypeServer[v.ServerType] = make([]*ServersConfig, 0, 10)
-			}
-			Conf.ServersConf.TypeServer[v.ServerType] = append(Conf.ServersConf.TypeServer[v.ServerType], v)
-		}
+// SetServersConf 替换servers快照
+func (c *Config) SetServersConf(serversConf ServersConf) {
+	serversConf.TypeServer = make(map[string][]*ServersConfig)
+	for _, v := range serversConf.Servers {
+		serversConf.TypeServer[v.ServerType] = append(serversConf.TypeServer[v.ServerType], v)
 	}
+	c.serversConf.Store(&serversConf)
+}
+
+func (c *Config) GameConfig() map[string]GameConfigValue {
+	return *c.gameConfig.Load()
+}
+
+// Settings 校验过的强类型配置
+func (c *Config) Settings() *Settings {
+	return c.settings.Load()
+}
+
+func (c *Config) ServersConf() *ServersConf {
+	return c.serversConf.Load()
 }
+
+// OnGameConfigChange 订阅配置项变化 key为空时订阅所有配置项
+func (c *Config) OnGameConfigChange(key string, fn GameConfigChangeFunc) {
+	c.subLock.Lock()
+	defer c.subLock.Unlock()
+	c.subscribers[key] = append(c.subscribers[key], fn)
+}
+
+func (c *Config) notify(key string, value GameConfigValue) {
+	c.subLock.RLock()
+	subs := make([]GameConfigChangeFunc, 0, len(c.subscribers[key])+len(c.subscribers[""]))
+	subs = append(subs, c.subscribers[key]...)
+	subs = append(subs, c.subscribers[""]...)
+	c.subLock.RUnlock()
+	for _, fn := range subs {
+		fn(key, value)
+	}
+}
+
 func (c *Config) GetConnector(serverId string) *ConnectorConfig {
 
-	for _, v := range c.ServersConf.Connector {
+	for _, v := range c.ServersConf().Connector {
 		if v.ID == serverId {
 			return v
 		}
 	}
 	return nil
 }
+func (c *Config) GetServer(serverId string) *ServersConfig {
+
+	for _, v := range c.ServersConf().Servers {
+		if v.ID == serverId {
+			return v
+		}
+	}
+	return nil
+}
+
+// DrainTimeout drain的等待时间 没有配置时默认5分钟
+func DrainTimeout(seconds int) time.Duration {
+	if seconds <= 0 {
+		return defaultDrainTimeout
+	}
+	return time.Duration(seconds) * time.Second
+}
+
 func (c *Config) GetConnectorByServerType(serverType string) *ConnectorConfig {
 
-	for _, v := range c.ServersConf.Connector {
+	for _, v := range c.ServersConf().Connector {
 		if v.ServerType == serverType {
 			return v
 		}
@@ -163,16 +249,23 @@ func (c *Config) GetConnectorByServerType(serverType string) *ConnectorConfig {
 }
 func (c *Config) GetFrontGameConfig() map[string]any {
 	result := make(map[string]any)
-	for k, v := range c.GameConfig {
-		value, ok := v["value"]
-		backend := false
-		_, exist := v["backend"]
-		if exist {
-			backend = v["backend"].(bool)
-		}
-		if ok && !backend {
+	for k, v := range c.GameConfig() {
+		if value, ok := v.FrontValue(); ok {
 			result[k] = value
 		}
 	}
 	return result
 }
+
+// FrontValue 客户端可见的配置值，backend为true的只在服务端使用
+func (v GameConfigValue) FrontValue() (any, bool) {
+	if v == nil {
+		return nil, false
+	}
+	value, ok := v["value"]
+	backend, _ := v["backend"].(bool)
+	if !ok || backend {
+		return nil, false
+	}
+	return value, true
+}
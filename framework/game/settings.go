@@ -0,0 +1,302 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Settings gameConfig.json中服务端会读取的配置项，加载时按settingSpecs校验并填充默认值
+// 业务代码通过Conf.Settings()读取，不再直接对map做类型断言
+type Settings struct {
+	StartGold             int64
+	FreeShopItem          bool
+	AuthPhone             bool
+	MinRechargeCount      int64
+	LoopBroadcastContent  string
+	LoopBroadcastInterval int64
+	UserMaxUnionCount     int64
+	ChatBannedWords       []string
+	SmsAuth               SmsAuthConfig
+}
+
+type SmsAuthConfig struct {
+	AccessKeyId     string
+	AccessKeySecret string
+	SignName        string
+	TemplateCode    string
+}
+
+type valueKind int
+
+const (
+	kindInt valueKind = iota
+	kindBool
+	kindString
+	kindObject
+	kindArray
+)
+
+func (k valueKind) String() string {
+	switch k {
+	case kindInt:
+		return "integer"
+	case kindBool:
+		return "boolean"
+	case kindString:
+		return "string"
+	case kindObject:
+		return "object"
+	case kindArray:
+		return "array"
+	}
+	return "unknown"
+}
+
+// settingSpec 配置项的schema 类似json schema的type/required/minimum/default
+type settingSpec struct {
+	key      string
+	kind     valueKind
+	required bool
+	min      int64
+	def      any
+	set      func(s *Settings, v any)
+}
+
+var settingSpecs = []settingSpec{
+	{key: "startGold", kind: kindInt, required: true, min: 0, set: func(s *Settings, v any) { s.StartGold = v.(int64) }},
+	{key: "freeShopItem", kind: kindBool, def: false, set: func(s *Settings, v any) { s.FreeShopItem = v.(bool) }},
+	{key: "authPhone", kind: kindBool, def: false, set: func(s *Settings, v any) { s.AuthPhone = v.(bool) }},
+	{key: "minRechargeCount", kind: kindInt, min: 0, def: int64(0), set: func(s *Settings, v any) { s.MinRechargeCount = v.(int64) }},
+	{key: "loopBroadcastContent", kind: kindString, def: "", set: func(s *Settings, v any) { s.LoopBroadcastContent = v.(string) }},
+	{key: "loopBroadcastInterval", kind: kindInt, min: 1, def: int64(300), set: func(s *Settings, v any) { s.LoopBroadcastInterval = v.(int64) }},
+	{key: "unionConfig", kind: kindObject, def: map[string]any{}, set: func(s *Settings, v any) {
+		s.UserMaxUnionCount = 20
+		if count, ok := v.(map[string]any)["userMaxUnionCount"].(float64); ok {
+			s.UserMaxUnionCount = int64(count)
+		}
+	}},
+	{key: "smsAuthConfig", kind: kindObject, def: map[string]any{}, set: func(s *Settings, v any) {
+		m := v.(map[string]any)
+		s.SmsAuth.AccessKeyId, _ = m["AccessKeyId"].(string)
+		s.SmsAuth.AccessKeySecret, _ = m["AccessKeySecret"].(string)
+		s.SmsAuth.SignName, _ = m["SignName"].(string)
+		s.SmsAuth.TemplateCode, _ = m["TemplateCode"].(string)
+	}},
+	{key: "unionActiveImgArr", kind: kindArray, def: []any{}},
+	{key: "chatBannedWords", kind: kindArray, def: []any{}, set: func(s *Settings, v any) {
+		for _, word := range v.([]any) {
+			if w, ok := word.(string); ok && len(w) > 0 {
+				s.ChatBannedWords = append(s.ChatBannedWords, w)
+			}
+		}
+	}},
+}
+
+// ValidationError 所有校验失败的配置项，一次全部列出
+type ValidationError struct {
+	File   string
+	Errors []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s invalid:\n  %s", e.File, strings.Join(e.Errors, "\n  "))
+}
+
+func (e *ValidationError) add(format string, args ...any) {
+	e.Errors = append(e.Errors, fmt.Sprintf(format, args...))
+}
+
+// ParseSettings 校验gameConfig并转换为Settings
+func ParseSettings(gameConfig map[string]GameConfigValue) (*Settings, error) {
+	s := &Settings{}
+	verr := &ValidationError{File: "gameConfig.json"}
+	for _, spec := range settingSpecs {
+		item, ok := gameConfig[spec.key]
+		var raw any
+		if ok {
+			raw, ok = item["value"]
+		}
+		if !ok {
+			if spec.required {
+				verr.add("%s.value: required", spec.key)
+				continue
+			}
+			raw = spec.def
+		}
+		v, err := convert(spec, raw)
+		if err != nil {
+			verr.add("%s.value: %v", spec.key, err)
+			continue
+		}
+		if spec.set != nil {
+			spec.set(s, v)
+		}
+	}
+	keys := make([]string, 0, len(gameConfig))
+	for k := range gameConfig {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if _, ok := gameConfig[k]["value"]; !ok {
+			verr.add("%s: missing \"value\"", k)
+		}
+		if backend, ok := gameConfig[k]["backend"]; ok {
+			if _, isBool := backend.(bool); !isBool {
+				verr.add("%s.backend: expected boolean, got %s", k, describe(backend))
+			}
+		}
+	}
+	if len(verr.Errors) > 0 {
+		return nil, verr
+	}
+	return s, nil
+}
+
+func convert(spec settingSpec, raw any) (any, error) {
+	switch spec.kind {
+	case kindInt:
+		var n int64
+		switch v := raw.(type) {
+		case float64:
+			if v != math.Trunc(v) {
+				return nil, fmt.Errorf("expected integer, got %v", v)
+			}
+			n = int64(v)
+		case int64:
+			n = v
+		default:
+			return nil, fmt.Errorf("expected integer, got %s", describe(raw))
+		}
+		if n < spec.min {
+			return nil, fmt.Errorf("must be >= %d, got %d", spec.min, n)
+		}
+		return n, nil
+	case kindBool:
+		switch v := raw.(type) {
+		case bool:
+			return v, nil
+		case string:
+			//历史配置中布尔值写成了字符串"true" "false"
+			switch strings.ToLower(v) {
+			case "true":
+				return true, nil
+			case "false":
+				return false, nil
+			}
+		}
+		return nil, fmt.Errorf("expected boolean or \"true\"/\"false\", got %s", describe(raw))
+	case kindString:
+		if v, ok := raw.(string); ok {
+			return v, nil
+		}
+	case kindObject:
+		if v, ok := raw.(map[string]any); ok {
+			return v, nil
+		}
+	case kindArray:
+		if v, ok := raw.([]any); ok {
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("expected %s, got %s", spec.kind, describe(raw))
+}
+
+func describe(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return fmt.Sprintf("string %q", v)
+	case float64:
+		return fmt.Sprintf("number %v", v)
+	case bool:
+		return fmt.Sprintf("boolean %v", v)
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// DefaultAdminToken 示例配置中出现过的token 不允许用它开启管理接口
+const DefaultAdminToken = "change-me"
+
+// ValidateServers 校验servers.json
+func ValidateServers(conf *ServersConf) error {
+	verr := &ValidationError{File: "servers.json"}
+	if len(conf.Nats.Url) == 0 {
+		verr.add("nats.url: required")
+	}
+	if conf.Nats.ReconnectWait < 0 || conf.Nats.MaxReconnects < 0 || conf.Nats.ReconnectBufSize < 0 {
+		verr.add("nats: reconnectWait, maxReconnects and reconnectBufSize must be >= 0")
+	}
+	ids := make(map[string]bool)
+	for i, v := range conf.Connector {
+		if len(v.ID) == 0 {
+			verr.add("connector[%d].id: required", i)
+		} else if ids[v.ID] {
+			verr.add("connector[%d].id: duplicated %q", i, v.ID)
+		}
+		ids[v.ID] = true
+		if v.ClientPort <= 0 || v.ClientPort > 65535 {
+			verr.add("connector[%d].clientPort: must be in 1-65535, got %d", i, v.ClientPort)
+		}
+		if v.AdminPort < 0 || v.AdminPort > 65535 {
+			verr.add("connector[%d].adminPort: must be in 0-65535, got %d", i, v.AdminPort)
+		}
+		if v.DrainTimeout < 0 {
+			verr.add("connector[%d].drainTimeout: must be >= 0, got %d", i, v.DrainTimeout)
+		}
+		if v.AdminPort > 0 && len(v.AdminToken) == 0 {
+			verr.add("connector[%d].adminToken: required when adminPort is set", i)
+		}
+		if v.AdminPort > 0 && v.AdminToken == DefaultAdminToken {
+			verr.add("connector[%d].adminToken: must not be the default %q", i, DefaultAdminToken)
+		}
+	}
+	for i, v := range conf.Servers {
+		if len(v.ID) == 0 {
+			verr.add("servers[%d].id: required", i)
+		} else if ids[v.ID] {
+			verr.add("servers[%d].id: duplicated %q", i, v.ID)
+		}
+		ids[v.ID] = true
+		if len(v.ServerType) == 0 {
+			verr.add("servers[%d].serverType: required", i)
+		}
+		if v.DrainTimeout < 0 {
+			verr.add("servers[%d].drainTimeout: must be >= 0, got %d", i, v.DrainTimeout)
+		}
+		if v.MaxRunRoutineNum < 0 {
+			verr.add("servers[%d].maxRunRoutineNum: must be >= 0, got %d", i, v.MaxRunRoutineNum)
+		}
+	}
+	if len(verr.Errors) > 0 {
+		return verr
+	}
+	return nil
+}
+
+// CheckConfigDir 离线校验配置目录 config check子命令使用
+func CheckConfigDir(configDir string) error {
+	errs := make([]error, 0)
+	values := make(map[string]GameConfigValue)
+	if err := readJSON(path.Join(configDir, gameConfig), &values); err != nil {
+		errs = append(errs, err)
+	} else if _, err := ParseSettings(values); err != nil {
+		errs = append(errs, err)
+	}
+	var serversConf ServersConf
+	if err := readJSON(path.Join(configDir, servers), &serversConf); err != nil {
+		errs = append(errs, err)
+	} else if err := ValidateServers(&serversConf); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
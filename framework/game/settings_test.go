@@ -0,0 +1,50 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSettings(t *testing.T) {
+	s, err := ParseSettings(map[string]GameConfigValue{
+		"startGold":    {"value": float64(10000), "backend": true},
+		"freeShopItem": {"value": "false", "backend": true},
+		"authPhone":    {"value": true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.StartGold != 10000 || s.FreeShopItem || !s.AuthPhone || s.LoopBroadcastInterval != 300 {
+		t.Fatalf("unexpected settings: %+v", s)
+	}
+
+	_, err = ParseSettings(map[string]GameConfigValue{
+		"startGold":    {"value": "10000"},
+		"freeShopItem": {"value": "no"},
+	})
+	if err == nil {
+		t.Fatal("invalid config should fail")
+	}
+	for _, want := range []string{"startGold.value: expected integer", "freeShopItem.value: expected boolean"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("error %q should contain %q", err, want)
+		}
+	}
+}
+
+func TestValidateServersAdminToken(t *testing.T) {
+	conf := &ServersConf{
+		Nats: NatsConfig{Url: "nats://127.0.0.1:4222"},
+		Connector: []*ConnectorConfig{
+			{ID: "connector001", ClientPort: 12000, AdminPort: 12100, AdminToken: DefaultAdminToken},
+		},
+	}
+	err := ValidateServers(conf)
+	if err == nil || !strings.Contains(err.Error(), "connector[0].adminToken: must not be the default") {
+		t.Fatalf("default admin token should fail, got %v", err)
+	}
+	conf.Connector[0].AdminPort = 0
+	if err := ValidateServers(conf); err != nil {
+		t.Fatalf("admin api disabled should pass, got %v", err)
+	}
+}
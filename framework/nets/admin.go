@@ -0,0 +1,101 @@
+package nets
+
+import (
+	"encoding/json"
+	"framework/protocol"
+	"sort"
+)
+
+// 运维管理接口使用的连接操作
+
+// Connections 当前所有连接，按连接时间排序
+func (m *Manager) Connections() []ConnectionInfo {
+	m.RLock()
+	list := make([]ConnectionInfo, 0, len(m.clients))
+	for _, v := range m.clients {
+		list = append(list, v.Info())
+	}
+	m.RUnlock()
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].ConnectedAt.Before(list[j].ConnectedAt)
+	})
+	return list
+}
+
+// FindByUid 同一个用户可能有多个连接(重复登录)
+func (m *Manager) FindByUid(uid string) []ConnectionInfo {
+	m.RLock()
+	defer m.RUnlock()
+	list := make([]ConnectionInfo, 0)
+	for _, v := range m.clients {
+		if v.GetSession().Uid == uid {
+			list = append(list, v.Info())
+		}
+	}
+	return list
+}
+
+// Kick 发送kick包之后断开用户的所有连接，返回断开的连接数
+func (m *Manager) Kick(uid string, reason string) (int, error) {
+	body, err := json.Marshal(map[string]any{
+		"reason": reason,
+	})
+	if err != nil {
+		return 0, err
+	}
+	buf, err := protocol.Encode(protocol.Kick, body)
+	if err != nil {
+		return 0, err
+	}
+	m.RLock()
+	targets := make([]Connection, 0)
+	for _, v := range m.clients {
+		if v.GetSession().Uid == uid {
+			targets = append(targets, v)
+		}
+	}
+	m.RUnlock()
+	for _, v := range targets {
+		v.SendMessage(buf)
+		v.Close()
+	}
+	return len(targets), nil
+}
+
+// Broadcast 以push消息推送给指定用户，users为空时推送给所有连接，返回推送的连接数
+func (m *Manager) Broadcast(users []string, route string, data any) (int, error) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return 0, err
+	}
+	buf, err := protocol.MessageEncode(&protocol.Message{
+		Type:  protocol.Push,
+		Route: route,
+		Data:  body,
+	})
+	if err != nil {
+		return 0, err
+	}
+	res, err := protocol.Encode(protocol.Data, buf)
+	if err != nil {
+		return 0, err
+	}
+	return m.sendToUsers(users, len(users) == 0, res), nil
+}
+
+// Push 推送给session满足filter的所有连接，业务按session数据筛选用户(联盟、游戏类型等)
+func (m *Manager) Push(filter func(*Session) bool, route string, data any) (int, error) {
+	m.RLock()
+	users := make([]string, 0)
+	for _, v := range m.clients {
+		session := v.GetSession()
+		if len(session.Uid) > 0 && filter(session) {
+			users = append(users, session.Uid)
+		}
+	}
+	m.RUnlock()
+	if len(users) == 0 {
+		return 0, nil
+	}
+	return m.Broadcast(users, route, data)
+}
@@ -1,9 +1,21 @@
 package nets
 
+import "time"
+
 type Connection interface {
 	Close()
 	SendMessage(buf []byte) error
 	GetSession() *Session
+	Info() ConnectionInfo
+}
+
+// ConnectionInfo 管理接口查看的连接信息
+type ConnectionInfo struct {
+	Cid         string    `json:"cid"`
+	Uid         string    `json:"uid"`
+	RemoteAddr  string    `json:"remoteAddr"`
+	ConnectedAt time.Time `json:"connectedAt"`
+	QueueDepth  int       `json:"queueDepth"` //待发送消息数
 }
 type MsgPack struct {
 	Cid  string
@@ -0,0 +1,95 @@
+package nets
+
+import (
+	"common/logs"
+	"context"
+	"encoding/json"
+	"framework/protocol"
+	"time"
+)
+
+const drainPushRoute = "ServerMessagePush"
+
+// Drain 不再接受新连接，通知客户端重连到其他connector
+// 等待客户端自己断开，ctx结束时踢掉剩余的连接
+func (m *Manager) Drain(ctx context.Context) {
+	if !m.draining.CompareAndSwap(false, true) {
+		return
+	}
+	count, err := m.Broadcast(nil, drainPushRoute, map[string]any{
+		"pushRouter": "ReconnectPush",
+	})
+	if err != nil {
+		logs.Error("push reconnect err:%v", err)
+	}
+	logs.Info("connector draining,notify connections=%d", count)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		if m.connectionCount() == 0 {
+			logs.Info("connector drained")
+			return
+		}
+		select {
+		case <-ctx.Done():
+			logs.Warn("connector drain timeout,kick connections=%d", m.kickAll("server maintenance"))
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Draining 是否正在drain
+func (m *Manager) Draining() bool {
+	return m.draining.Load()
+}
+
+// DrainingServers 当前正在drain的节点
+func (m *Manager) DrainingServers() []string {
+	list := make([]string, 0)
+	m.drainingServers.Range(func(key, value any) bool {
+		list = append(list, key.(string))
+		return true
+	})
+	return list
+}
+
+func (m *Manager) setServerState(serverId string, draining bool) {
+	if draining {
+		m.drainingServers.Store(serverId, struct{}{})
+		logs.Info("server %s draining,stop routing new requests", serverId)
+		return
+	}
+	if _, ok := m.drainingServers.LoadAndDelete(serverId); ok {
+		logs.Info("server %s ready", serverId)
+	}
+}
+
+func (m *Manager) connectionCount() int {
+	m.RLock()
+	defer m.RUnlock()
+	return len(m.clients)
+}
+
+// kickAll 发送kick包之后断开所有连接
+func (m *Manager) kickAll(reason string) int {
+	body, _ := json.Marshal(map[string]any{
+		"reason": reason,
+	})
+	buf, err := protocol.Encode(protocol.Kick, body)
+	if err != nil {
+		logs.Error("encode kick packet err:%v", err)
+		return 0
+	}
+	m.RLock()
+	targets := make([]Connection, 0, len(m.clients))
+	for _, v := range m.clients {
+		targets = append(targets, v)
+	}
+	m.RUnlock()
+	for _, v := range targets {
+		v.SendMessage(buf)
+		v.Close()
+	}
+	return len(targets)
+}
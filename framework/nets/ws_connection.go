@@ -2,9 +2,11 @@ package nets
 
 import (
 	"common/logs"
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
+	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -17,25 +19,34 @@ var (
 	pingInterval         = (PongWait * 9) / 10
 )
 
+var errConnectionClosed = errors.New("connection closed")
+
 type WsConnection struct {
-	Cid        string //客户端id
-	Conn       *websocket.Conn
-	manager    *Manager
-	ReadChan   chan *MsgPack
-	WriteChan  chan []byte
-	Session    *Session
-	pingTicker *time.Ticker
+	Cid         string //客户端id
+	Conn        *websocket.Conn
+	manager     *Manager
+	ReadChan    chan *MsgPack
+	WriteChan   chan []byte
+	Session     *Session
+	pingTicker  *time.Ticker
+	RemoteAddr  string
+	ConnectedAt time.Time
+	closeChan   chan struct{}
+	closeOnce   sync.Once
 }
 
 func NewWsConnection(conn *websocket.Conn, manager *Manager) *WsConnection {
 	cid := fmt.Sprintf("%s-%s-%d", uuid.New().String(), manager.ServerId, atomic.AddUint64(&CidBase, 1))
 	return &WsConnection{
-		Conn:      conn,
-		manager:   manager,
-		Cid:       cid,
-		WriteChan: make(chan []byte, 1024),
-		ReadChan:  manager.ClientReadChan,
-		Session:   NewSession(cid),
+		Conn:        conn,
+		manager:     manager,
+		Cid:         cid,
+		WriteChan:   make(chan []byte, 1024),
+		ReadChan:    manager.ClientReadChan,
+		Session:     NewSession(cid),
+		RemoteAddr:  conn.RemoteAddr().String(),
+		ConnectedAt: time.Now(),
+		closeChan:   make(chan struct{}),
 	}
 
 }
@@ -55,12 +66,20 @@ func (c *WsConnection) PongHandler(data string) error {
 	return nil
 }
 
+// Close 通知写协程把队列中剩余的消息发完之后再断开连接，保证踢人等最后一条消息能送达
 func (c *WsConnection) Close() {
-	if c.Conn != nil {
-		c.Conn.Close()
-	}
-	if c.pingTicker != nil {
-		c.pingTicker.Stop()
+	c.closeOnce.Do(func() {
+		close(c.closeChan)
+	})
+}
+
+func (c *WsConnection) Info() ConnectionInfo {
+	return ConnectionInfo{
+		Cid:         c.Cid,
+		Uid:         c.Session.Uid,
+		RemoteAddr:  c.RemoteAddr,
+		ConnectedAt: c.ConnectedAt,
+		QueueDepth:  len(c.WriteChan),
 	}
 }
 func (c *WsConnection) GetSession() *Session {
@@ -100,8 +119,12 @@ func (c *WsConnection) writeMessage() {
 	//	c.pingTicker.Stop()
 	//}
 	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
 	for {
 		select {
+		case <-c.closeChan:
+			c.flush()
+			return
 		case message, ok := <-c.WriteChan:
 			if !ok {
 				if err := c.Conn.WriteMessage(websocket.CloseMessage, nil); err != nil {
@@ -124,7 +147,33 @@ func (c *WsConnection) writeMessage() {
 	}
 
 }
+func (c *WsConnection) flush() {
+	defer c.Conn.Close()
+	for {
+		select {
+		case message := <-c.WriteChan:
+			_ = c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.Conn.WriteMessage(websocket.BinaryMessage, message); err != nil {
+				return
+			}
+		default:
+			_ = c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
+			_ = c.Conn.WriteMessage(websocket.CloseMessage, nil)
+			return
+		}
+	}
+}
+
 func (c *WsConnection) SendMessage(buf []byte) error {
-	c.WriteChan <- buf
-	return nil
+	select {
+	case <-c.closeChan:
+		return errConnectionClosed
+	default:
+	}
+	select {
+	case c.WriteChan <- buf:
+		return nil
+	case <-c.closeChan:
+		return errConnectionClosed
+	}
 }
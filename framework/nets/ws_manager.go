@@ -2,7 +2,10 @@ package nets
 
 import (
 	"common/logs"
+	"common/metrics"
+	"common/trace"
 	"common/utils"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -14,9 +17,13 @@ import (
 	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// 心跳每个连接几秒一次，只采样输出
+var heartbeatLog = logs.Sample(100)
+
 var (
 	websocketUpgrade = websocket.Upgrader{
 		CheckOrigin: func(r *http.Request) bool {
@@ -40,8 +47,13 @@ type Manager struct {
 	RemoteReadChan    chan []byte
 	RemoteCli         remote.Client
 	RemotePushChan    chan *remote.Msg
+	draining          atomic.Bool
+	drainingServers   sync.Map //正在drain的节点 serverId -> struct{}
+	remoteRoutes      sync.Map //远端节点响应过的路由 route -> struct{} 只有这些路由作为指标标签
 }
-type HandleFunc func(session *Session, body []byte) (any, error)
+
+// HandleFunc ctx中携带这条消息的traceId 同一个连接上的消息可能并发处理，traceId不能放在session中
+type HandleFunc func(ctx context.Context, session *Session, body []byte) (any, error)
 type LogicHandler map[string]HandleFunc
 type EventHandler func(packet *protocol.Packet, c Connection) error
 
@@ -68,6 +80,12 @@ func (m *Manager) Run(addr string) {
 }
 
 func (m *Manager) serveWS(w http.ResponseWriter, r *http.Request) {
+	if m.draining.Load() {
+		//负载均衡根据503摘掉当前connector
+		w.Header().Set("Retry-After", "5")
+		http.Error(w, "connector draining", http.StatusServiceUnavailable)
+		return
+	}
 
 	if m.websocketUpgrade == nil {
 		m.websocketUpgrade = &websocketUpgrade
@@ -86,16 +104,62 @@ func (m *Manager) addClient(client *WsConnection) {
 	m.Lock()
 	defer m.Unlock()
 	m.clients[client.Cid] = client
+	metrics.ConnectorConnections.Inc()
 }
 
 func (m *Manager) removeClient(wc *WsConnection) {
-	for cid, c := range m.clients {
-		if cid == wc.Cid {
-			c.Close()
-			delete(m.clients, cid)
+	m.Lock()
+	c, ok := m.clients[wc.Cid]
+	if ok {
+		c.Close()
+		delete(m.clients, wc.Cid)
+		metrics.ConnectorConnections.Dec()
+	}
+	m.Unlock()
+	if ok {
+		m.notifyClose(c.GetSession())
+	}
+}
+
+// notifyClose 通知session绑定的节点客户端已经断开，例如房间将用户标记为掉线
+func (m *Manager) notifyClose(session *Session) {
+	if len(session.Uid) == 0 || m.RemoteCli == nil {
+		return
+	}
+	for serverType := range game.Conf.ServersConf().TypeServer {
+		v, ok := session.Get(remote.ServerKey(serverType))
+		if !ok {
+			continue
+		}
+		dst, _ := v.(string)
+		if len(dst) == 0 {
+			continue
+		}
+		data, err := remote.MsgEncode(&remote.Msg{
+			Cid:         session.Cid,
+			Uid:         session.Uid,
+			Src:         m.ServerId,
+			Dst:         dst,
+			Router:      remote.CloseRouter,
+			SessionData: session.data,
+			Type:        remote.CloseType,
+		})
+		if err != nil {
+			logs.Error("remote encode close msg err: %v", err)
+			continue
+		}
+		if err := m.RemoteCli.SendMsg(dst, data); err != nil {
+			logs.Error("notify close err: %v,dst=%s,uid=%s", err, dst, session.Uid)
 		}
 	}
 }
+
+func (m *Manager) getClient(cid string) (Connection, bool) {
+	m.RLock()
+	defer m.RUnlock()
+	c, ok := m.clients[cid]
+	return c, ok
+}
 func (m *Manager) ClientReadChanHandler() {
 	for {
 		select {
@@ -116,20 +180,23 @@ func (m *Manager) decodeClientPack(body *MsgPack) {
 	}
 	if err := m.routeEvent(packet, body.Cid); err != nil {
 
-		logs.Error("routeEvent err11111111111: %v", err)
+		logs.Error("routeEvent err: %v,cid=%s", err, body.Cid)
 	}
 }
 
 func (m *Manager) Close() {
+	m.Lock()
+	defer m.Unlock()
 	for cid, v := range m.clients {
 		v.Close()
 		delete(m.clients, cid)
 	}
+	metrics.ConnectorConnections.Set(0)
 
 }
 func (m *Manager) routeEvent(packet *protocol.Packet, cid string) error {
 	//根据packet.type做不同处理
-	conn, ok := m.clients[cid]
+	conn, ok := m.getClient(cid)
 	if ok {
 		handler, ok := m.handlers[packet.Type]
 		if ok {
@@ -167,11 +234,11 @@ func (m *Manager) HandshakeHandler(packet *protocol.Packet, c Connection) error
 	return c.SendMessage(buf)
 }
 func (m *Manager) HandshakeAckHandler(packet *protocol.Packet, c Connection) error {
-	logs.Info("receiver Hands hakeAckHandler message >>>>> ")
+	logs.Debug("receiver HandshakeAckHandler message,cid=%s", c.GetSession().Cid)
 	return nil
 }
 func (m *Manager) HeartbeatHandler(packet *protocol.Packet, c Connection) error {
-	logs.Info("receiver HeartbeatHandler message :%v ", packet.Type)
+	heartbeatLog.Debug("receiver HeartbeatHandler message,cid=%s", c.GetSession().Cid)
 	var res []byte
 	data, err := json.Marshal(res)
 	buf, err := protocol.Encode(packet.Type, data)
@@ -186,10 +253,14 @@ func (m *Manager) HeartbeatHandler(packet *protocol.Packet, c Connection) error
 func (m *Manager) MessageHandler(packet *protocol.Packet, c Connection) error {
 
 	message := packet.MessageBody()
-	logs.Info("receiver MessageHandler message, type=%v router=%v,data:%v ", message.Type, message.Route, string(message.Data))
+	//每条客户端消息生成traceId，随remote.Msg透传到处理的节点
+	traceId := trace.NewId()
+	log := logs.WithTrace(traceId).With(logs.KeyUid, c.GetSession().Uid, logs.KeyRoute, message.Route)
+	log.Debug("receiver MessageHandler message, type=%v,data:%v", message.Type, string(message.Data))
 	routeStr := message.Route
 	routers := strings.Split(routeStr, ".")
 	if len(routers) != 3 {
+		metrics.ConnectorMessages.WithLabelValues(unknownRoute).Inc()
 		return errors.New("route err")
 	}
 	serverType := routers[0]
@@ -198,7 +269,10 @@ func (m *Manager) MessageHandler(packet *protocol.Packet, c Connection) error {
 	if connectorConfig != nil {
 		handle, ok := m.ConnectorHandlers[HandleMethod]
 		if ok {
-			data, err := handle(c.GetSession(), message.Data)
+			metrics.ConnectorMessages.WithLabelValues(routeStr).Inc()
+			start := time.Now()
+			data, err := handle(trace.WithContext(context.Background(), traceId), c.GetSession(), message.Data)
+			metrics.ObserveHandler(HandleMethod, start)
 			if err != nil {
 				return err
 			}
@@ -214,14 +288,16 @@ func (m *Manager) MessageHandler(packet *protocol.Packet, c Connection) error {
 			}
 			return c.SendMessage(res)
 		}
+		metrics.ConnectorMessages.WithLabelValues(unknownRoute).Inc()
 	} else {
 		//nats远端调用处理 hall.userHandler.updateUserAddress
 
-		dst, err := m.selectDst(serverType)
+		dst, err := m.selectDst(serverType, c.GetSession())
 		if err != nil {
-			logs.Error("selectDst err: %v", err)
+			log.Error("selectDst err: %v", err)
 			return err
 		}
+		metrics.ConnectorMessages.WithLabelValues(m.remoteRouteLabel(routeStr)).Inc()
 		msg := &remote.Msg{
 			Cid:         c.GetSession().Cid,
 			Uid:         c.GetSession().Uid,
@@ -230,11 +306,16 @@ func (m *Manager) MessageHandler(packet *protocol.Packet, c Connection) error {
 			Router:      HandleMethod,
 			Body:        message,
 			SessionData: c.GetSession().data,
+			TraceId:     traceId,
+		}
+		data, err := remote.MsgEncode(msg)
+		if err != nil {
+			log.Error("remote encode msg err: %v", err)
+			return err
 		}
-		data, _ := json.Marshal(msg)
 		err = m.RemoteCli.SendMsg(dst, data)
 		if err != nil {
-			logs.Error("remote send msg err: %v", err)
+			log.Error("remote send msg err: %v", err)
 			return err
 		}
 
@@ -243,7 +324,7 @@ func (m *Manager) MessageHandler(packet *protocol.Packet, c Connection) error {
 
 }
 func (m *Manager) KickHandler(packet *protocol.Packet, c Connection) error {
-	logs.Info("receiver KickHandler message >>>>> ")
+	logs.Debug("receiver KickHandler message,cid=%s", c.GetSession().Cid)
 
 	return nil
 }
@@ -252,26 +333,33 @@ func (m *Manager) RemoteReadChanHandler() {
 		select {
 		case body, ok := <-m.RemoteReadChan:
 			if ok {
-				logs.Info("sub nats msg :%v", string(body))
-				var msg remote.Msg
-				err := json.Unmarshal(body, &msg)
+				msg, err := remote.MsgDecode(body)
 				if err != nil {
 					logs.Error("nats remote message format err: %v", err)
 					continue
 				}
+				logs.WithTrace(msg.TraceId).With(logs.KeyUid, msg.Uid).Debug("sub nats msg :%s", msg.Router)
 				if msg.Type == remote.SessionType {
-					m.setSessionData(msg)
+					m.setSessionData(*msg)
 
 					continue
 
 				}
+				if msg.Type == remote.DrainType || msg.Type == remote.ReadyType {
+					m.setServerState(msg.Src, msg.Type == remote.DrainType)
+					continue
+				}
 				if msg.Body != nil {
+					if msg.Body.Type != protocol.Push {
+						//节点只对注册了handler的路由返回消息
+						m.remoteRoutes.Store(msg.Body.Route, struct{}{})
+					}
 					if msg.Body.Type == protocol.Request || msg.Body.Type == protocol.Response {
 						msg.Body.Type = protocol.Response
-						m.Response(&msg)
+						m.Response(msg)
 					}
 					if msg.Body.Type == protocol.Push {
-						m.RemotePushChan <- &msg
+						m.RemotePushChan <- msg
 					}
 
 				}
@@ -280,50 +368,104 @@ func (m *Manager) RemoteReadChanHandler() {
 	}
 
 }
-func (m *Manager) selectDst(serverType string) (string, error) {
-	serversConfigs, ok := game.Conf.ServersConf.TypeServer[serverType]
+func (m *Manager) selectDst(serverType string, session *Session) (string, error) {
+	serversConfigs, ok := game.Conf.ServersConf().TypeServer[serverType]
 	if !ok {
 		return "", errors.New("not found serverType")
 	}
-
+	//用户在节点上有状态(例如房间) 继续路由到该节点，即使节点正在drain
+	if v, ok := session.Get(remote.ServerKey(serverType)); ok {
+		for _, server := range serversConfigs {
+			if server.ID == v {
+				return server.ID, nil
+			}
+		}
+	}
+	available := make([]*game.ServersConfig, 0, len(serversConfigs))
+	for _, v := range serversConfigs {
+		if _, draining := m.drainingServers.Load(v.ID); !draining {
+			available = append(available, v)
+		}
+	}
+	if len(available) == 0 {
+		return "", fmt.Errorf("all %s servers are draining", serverType)
+	}
+	//所有服务器都在队列组中并且没有drain 交给nats选择其中一个服务器
+	//否则按服务器选择 直接发送到选中服务器自己的主题
+	if len(available) == len(serversConfigs) && allQueue(available) {
+		return remote.QueueSubject(serverType), nil
+	}
 	//随机
 	rand.New(rand.NewSource(time.Now().UnixNano()))
-	index := rand.Intn(len(serversConfigs))
-	return serversConfigs[index].ID, nil
+	index := rand.Intn(len(available))
+	return available[index].ID, nil
 
 }
 
-func (m *Manager) Response(msg *remote.Msg) {
-	connection, ok := m.clients[msg.Cid]
-	if !ok {
-		logs.Info("%s client  not found,uid=%s", msg.Cid, msg.Uid)
-		return
+// unknownRoute 没有注册的路由统一的指标标签 避免客户端构造的路由撑爆标签数量
+const unknownRoute = "unknown"
+
+// remoteRouteLabel 远端节点响应过的路由才作为标签
+func (m *Manager) remoteRouteLabel(route string) string {
+	if _, ok := m.remoteRoutes.Load(route); ok {
+		return route
+	}
+	return unknownRoute
+}
+
+func allQueue(servers []*game.ServersConfig) bool {
+	for _, v := range servers {
+		if !v.Queue {
+			return false
+		}
 	}
+	return true
+}
+
+func (m *Manager) Response(msg *remote.Msg) {
+	log := logs.WithTrace(msg.TraceId).With(logs.KeyUid, msg.Uid)
 	buf, err := protocol.MessageEncode(msg.Body)
 	if err != nil {
-		logs.Error(" response encode message err: %v", err)
+		log.Error(" response encode message err: %v", err)
 		return
 	}
 	res, err := protocol.Encode(protocol.Data, buf)
 	if err != nil {
-		logs.Error(" message encode err: %v", err)
+		log.Error(" message encode err: %v", err)
 
 		return
 	}
 	if msg.Body.Type == protocol.Push {
+		//推送给当前connector上所有在PushUser中的用户，不依赖发起请求的连接
+		m.sendToUsers(msg.PushUser, false, res)
+		return
+	}
+	connection, ok := m.getClient(msg.Cid)
+	if !ok {
+		log.Debug("%s client  not found", msg.Cid)
+		return
+	}
+	connection.SendMessage(res)
 
-		for _, v := range m.clients {
-
-			if utils.Contains(msg.PushUser, v.GetSession().Uid) {
-				v.SendMessage(res)
-				return
-
-			}
+}
 
+// sendToUsers all为true时发送给所有连接，返回发送的连接数
+func (m *Manager) sendToUsers(users []string, all bool, buf []byte) int {
+	m.RLock()
+	targets := make([]Connection, 0)
+	for _, v := range m.clients {
+		if all || utils.Contains(users, v.GetSession().Uid) {
+			targets = append(targets, v)
 		}
 	}
-	connection.SendMessage(res)
-
+	m.RUnlock()
+	count := 0
+	for _, v := range targets {
+		if v.SendMessage(buf) == nil {
+			count++
+		}
+	}
+	return count
 }
 
 func (m *Manager) RemotePushChanHandler() {
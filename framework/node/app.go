@@ -2,46 +2,122 @@ package node
 
 import (
 	"common/logs"
+	"common/metrics"
 	"encoding/json"
 	"framework/remote"
+	"sync"
+	"time"
 )
 
 type App struct {
-	remoteCli remote.Client
-	readChan  chan []byte
-	writeChan chan *remote.Msg
-	handlers  LogicHandler
+	serverId   string
+	remoteCli  remote.Client
+	readChan   chan []byte
+	dispatcher *remote.PushDispatcher
+	handlers   LogicHandler
+	closeChan  chan struct{}
+	closeOnce  sync.Once
+	wg         sync.WaitGroup
+	drainChan  chan struct{}
+	drainOnce  sync.Once
 }
 
 func Default() *App {
 	return &App{
 		readChan:  make(chan []byte, 1024),
-		writeChan: make(chan *remote.Msg, 1024),
 		handlers:  make(LogicHandler),
+		closeChan: make(chan struct{}),
+		drainChan: make(chan struct{}),
 	}
 }
 func (a *App) Run(serverId string) error {
+	a.serverId = serverId
 	a.remoteCli = remote.NewNatClient(serverId, a.readChan)
 	err := a.remoteCli.Run()
 	if err != nil {
 		return err
 	}
+	//push session数据 以及handler的响应 都通过dispatcher按目标顺序发送
+	a.dispatcher = remote.NewPushDispatcher(a.remoteCli, 1024)
+	a.wg.Add(1)
 	go a.readChanMsg()
-	go a.writeChanMsg()
+	//重启之后通知connector恢复分配请求
+	a.broadcastState(remote.ReadyType)
 	return nil
 }
+
+// NewSession 不对应任何请求的session，节点主动向connectorId推送消息时使用(例如恢复的房间的定时任务)
+func (a *App) NewSession(connectorId string) *remote.Session {
+	return remote.NewSession(a.dispatcher, &remote.Msg{
+		Src: connectorId,
+		Dst: a.serverId,
+	})
+}
+
+// Drain 通知所有connector不再把新请求分配到当前节点，已经绑定到当前节点的用户继续路由过来
+// 收到connector管理接口转发的drain命令时同样调用
+func (a *App) Drain() {
+	a.drainOnce.Do(func() {
+		a.broadcastState(remote.DrainType)
+		close(a.drainChan)
+	})
+}
+
+// Draining 进入drain状态之后关闭
+func (a *App) Draining() <-chan struct{} {
+	return a.drainChan
+}
+
+func (a *App) broadcastState(msgType int) {
+	if a.remoteCli == nil {
+		return
+	}
+	data, err := remote.MsgEncode(&remote.Msg{
+		Src:  a.serverId,
+		Type: msgType,
+	})
+	if err != nil {
+		logs.Error("encode server state err:%v", err)
+		return
+	}
+	if err := a.remoteCli.SendMsg(remote.StateSubject, data); err != nil {
+		logs.Error("broadcast server state err:%v", err)
+	}
+}
 func (a *App) readChanMsg() {
+	defer a.wg.Done()
 	//收到其他nats client发送的消息
 	for {
 		select {
+		case <-a.closeChan:
+			return
 		case msg := <-a.readChan:
-			var remoteMsg remote.Msg
-			json.Unmarshal(msg, &remoteMsg)
-			session := remote.NewSession(a.remoteCli, &remoteMsg)
+			remoteMsg, err := remote.MsgDecode(msg)
+			if err != nil {
+				logs.Error("nats remote message decode err:%v", err)
+				continue
+			}
+			if remoteMsg.Type == remote.DrainType {
+				logs.Info("receive drain command from %s", remoteMsg.Src)
+				a.Drain()
+				continue
+			}
+			//通过队列组收到的消息 Dst是队列主题，替换为当前服务器 响应和推送的Src才是准确的
+			remoteMsg.Dst = a.serverId
+			session := remote.NewSession(a.dispatcher, remoteMsg)
 			session.SetData(remoteMsg.SessionData)
+			if remoteMsg.Type == remote.CloseType {
+				//连接断开的通知 不需要响应
+				if handlerFunc := a.handlers[remote.CloseRouter]; handlerFunc != nil {
+					handlerFunc(session, nil)
+				}
+				continue
+			}
 			router := remoteMsg.Router
 			if handlerFunc := a.handlers[router]; handlerFunc != nil {
+				start := time.Now()
 				result := handlerFunc(session, remoteMsg.Body.Data)
+				metrics.ObserveHandler(router, start)
 				message := remoteMsg.Body
 				var body []byte
 				if result != nil {
@@ -49,40 +125,37 @@ func (a *App) readChanMsg() {
 				}
 				message.Data = body
 				responseMsg := &remote.Msg{
-					Src:  remoteMsg.Dst,
-					Dst:  remoteMsg.Src,
-					Body: message,
-					Uid:  remoteMsg.Uid,
-					Cid:  remoteMsg.Cid,
+					Src:     remoteMsg.Dst,
+					Dst:     remoteMsg.Src,
+					Body:    message,
+					Uid:     remoteMsg.Uid,
+					Cid:     remoteMsg.Cid,
+					TraceId: remoteMsg.TraceId,
 				}
-				a.writeChan <- responseMsg
-			}
-
-		}
-	}
-}
-func (a *App) writeChanMsg() {
-	for {
-		select {
-		case msg, ok := <-a.writeChan:
-			if ok {
-				marshal, _ := json.Marshal(msg)
-				err := a.remoteCli.SendMsg(msg.Dst, marshal)
-				if err != nil {
-					logs.Error("send message to remote server error", err)
+				if err := a.dispatcher.Dispatch(responseMsg); err != nil {
+					session.Log().Error("send message to remote server err:%v", err)
 				}
+			} else {
+				session.Log().Warn("handler not found,router=%s,uid=%s", router, remoteMsg.Uid)
 			}
 
 		}
 	}
-
 }
 
+// Close 停止处理新消息，等待推送队列发送完成之后再断开nats
 func (a *App) Close() {
-	if a.remoteCli != nil {
-		a.remoteCli.Close()
+	a.closeOnce.Do(func() {
+		close(a.closeChan)
+		a.wg.Wait()
+		if a.dispatcher != nil {
+			a.dispatcher.Close()
+		}
+		if a.remoteCli != nil {
+			a.remoteCli.Close()
 
-	}
+		}
+	})
 
 }
 
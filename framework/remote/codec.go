@@ -0,0 +1,209 @@
+package remote
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"framework/protocol"
+)
+
+// 服务器之间传递Msg的二进制信封格式
+// ------------------------------------------------------------
+// | version | header length |      header      |    body     |
+// |---------|---------------|------------------|-------------|
+// | 1 byte  |   uvarint     | header length 字节 | 剩余全部字节 |
+// ------------------------------------------------------------
+// header中字符串均为 uvarint长度 + 原始字节，SessionData因为值类型不确定仍然使用json
+// body为protocol.Message.Data的原始字节，不再做base64，避免push数据被二次编码
+// json编码的Msg第一个字节一定是'{'，所以解码时可以兼容旧的json格式
+// version 2 在Router之后增加TraceId，解码仍然兼容version 1
+
+const (
+	MsgCodecVersion   byte = 0x02
+	msgCodecVersionV1 byte = 0x01
+	jsonMsgPrefix     byte = '{'
+)
+
+const (
+	bodyNone    byte = 0x00
+	bodyPresent byte = 0x01
+)
+
+var (
+	ErrMsgTooShort       = errors.New("remote msg data len invalid")
+	ErrMsgVersionInvalid = errors.New("remote msg codec version unsupported")
+)
+
+// MsgEncode 将Msg编码为二进制信封
+func MsgEncode(msg *Msg) ([]byte, error) {
+	if msg == nil {
+		return nil, errors.New("remote msg is nil")
+	}
+	header := make([]byte, 0, 128)
+	header = binary.AppendUvarint(header, uint64(msg.Type))
+	header = appendString(header, msg.Cid)
+	header = appendString(header, msg.Uid)
+	header = appendString(header, msg.Src)
+	header = appendString(header, msg.Dst)
+	header = appendString(header, msg.Router)
+	header = appendString(header, msg.TraceId)
+	header = binary.AppendUvarint(header, uint64(len(msg.PushUser)))
+	for _, v := range msg.PushUser {
+		header = appendString(header, v)
+	}
+	var sessionData []byte
+	if msg.SessionData != nil {
+		var err error
+		sessionData, err = json.Marshal(msg.SessionData)
+		if err != nil {
+			return nil, err
+		}
+	}
+	header = appendBytes(header, sessionData)
+	var data []byte
+	if msg.Body == nil {
+		header = append(header, bodyNone)
+	} else {
+		header = append(header, bodyPresent, byte(msg.Body.Type))
+		header = binary.AppendUvarint(header, uint64(msg.Body.ID))
+		header = appendString(header, msg.Body.Route)
+		if msg.Body.Error {
+			header = append(header, 1)
+		} else {
+			header = append(header, 0)
+		}
+		data = msg.Body.Data
+	}
+
+	buf := make([]byte, 0, 1+binary.MaxVarintLen64+len(header)+len(data))
+	buf = append(buf, MsgCodecVersion)
+	buf = binary.AppendUvarint(buf, uint64(len(header)))
+	buf = append(buf, header...)
+	buf = append(buf, data...)
+	return buf, nil
+}
+
+// MsgDecode 解析二进制信封，兼容json格式的Msg
+func MsgDecode(data []byte) (*Msg, error) {
+	if len(data) < 1 {
+		return nil, ErrMsgTooShort
+	}
+	msg := &Msg{}
+	if data[0] == jsonMsgPrefix {
+		if err := json.Unmarshal(data, msg); err != nil {
+			return nil, err
+		}
+		return msg, nil
+	}
+	version := data[0]
+	if version != MsgCodecVersion && version != msgCodecVersionV1 {
+		return nil, ErrMsgVersionInvalid
+	}
+	r := &msgReader{buf: data, offset: 1}
+	headerLen := r.uvarint()
+	if r.err != nil || uint64(len(data)-r.offset) < headerLen {
+		return nil, ErrMsgTooShort
+	}
+	bodyOffset := r.offset + int(headerLen)
+	r.buf = data[:bodyOffset]
+
+	msg.Type = int(r.uvarint())
+	msg.Cid = r.string()
+	msg.Uid = r.string()
+	msg.Src = r.string()
+	msg.Dst = r.string()
+	msg.Router = r.string()
+	if version >= MsgCodecVersion {
+		msg.TraceId = r.string()
+	}
+	if count := r.uvarint(); count > 0 && r.err == nil {
+		if count > uint64(len(r.buf)-r.offset) {
+			return nil, ErrMsgTooShort
+		}
+		msg.PushUser = make([]string, count)
+		for i := range msg.PushUser {
+			msg.PushUser[i] = r.string()
+		}
+	}
+	if sessionData := r.bytes(); len(sessionData) > 0 {
+		if err := json.Unmarshal(sessionData, &msg.SessionData); err != nil {
+			return nil, err
+		}
+	}
+	if r.byte() == bodyPresent {
+		body := &protocol.Message{}
+		body.Type = protocol.MessageType(r.byte())
+		body.ID = uint(r.uvarint())
+		body.Route = r.string()
+		body.Error = r.byte() == 1
+		if bodyOffset < len(data) {
+			body.Data = data[bodyOffset:]
+		}
+		msg.Body = body
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return msg, nil
+}
+
+func appendString(buf []byte, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+func appendBytes(buf []byte, b []byte) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(b)))
+	return append(buf, b...)
+}
+
+// msgReader 顺序读取header，出错之后的读取全部返回零值，最后统一判断err
+type msgReader struct {
+	buf    []byte
+	offset int
+	err    error
+}
+
+func (r *msgReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.buf[r.offset:])
+	if n <= 0 {
+		r.err = ErrMsgTooShort
+		return 0
+	}
+	r.offset += n
+	return v
+}
+
+func (r *msgReader) byte() byte {
+	if r.err != nil {
+		return 0
+	}
+	if r.offset >= len(r.buf) {
+		r.err = ErrMsgTooShort
+		return 0
+	}
+	b := r.buf[r.offset]
+	r.offset++
+	return b
+}
+
+func (r *msgReader) bytes() []byte {
+	l := r.uvarint()
+	if r.err != nil {
+		return nil
+	}
+	if l > uint64(len(r.buf)-r.offset) {
+		r.err = ErrMsgTooShort
+		return nil
+	}
+	b := r.buf[r.offset : r.offset+int(l)]
+	r.offset += int(l)
+	return b
+}
+
+func (r *msgReader) string() string {
+	return string(r.bytes())
+}
@@ -0,0 +1,100 @@
+package remote
+
+import (
+	"bytes"
+	"encoding/json"
+	"framework/protocol"
+	"reflect"
+	"testing"
+)
+
+func newTestMsg() *Msg {
+	data, _ := json.Marshal(map[string]any{
+		"type": 404,
+		"data": map[string]any{
+			"handCards": [][]int{{1, 1, 2, 3, 5, 5, 5, 11, 11, 11, 35, 14, 17}, {36, 36, 36, 36, 36, 36, 36, 36, 36, 36, 36, 36, 36}},
+			"chairID":   0,
+		},
+		"pushRouter": "GameMessagePush",
+	})
+	return &Msg{
+		Cid:    "9d5c6e0e-2f4b-4bb4-9a8e-1b1e0b3f6a11-connector001-10001",
+		Uid:    "100000001",
+		Src:    "game-001",
+		Dst:    "connector001",
+		Router: "gameHandler.gameMessageNotify",
+		Body: &protocol.Message{
+			Type:  protocol.Push,
+			ID:    12,
+			Route: "ServerMessagePush",
+			Data:  data,
+		},
+		SessionData: map[string]any{"roomId": "336842"},
+		PushUser:    []string{"100000001", "100000002"},
+		TraceId:     "3f2a9c1e7b4d4e0f8a6b5c4d3e2f1a0b",
+	}
+}
+
+func TestMsgCodec(t *testing.T) {
+	msg := newTestMsg()
+	buf, err := MsgEncode(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := MsgDecode(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Body.Data, msg.Body.Data) {
+		t.Fatalf("body data mismatch: %s", got.Body.Data)
+	}
+	got.Body.Data, msg.Body.Data = nil, nil
+	if !reflect.DeepEqual(got, msg) {
+		t.Fatalf("decode msg mismatch: %+v", got)
+	}
+
+	jsonBuf, _ := json.Marshal(newTestMsg())
+	if _, err := MsgDecode(jsonBuf); err != nil {
+		t.Fatalf("decode json msg err: %v", err)
+	}
+	if _, err := MsgDecode(buf[:len(buf)/3]); err == nil {
+		t.Fatal("decode truncated msg should fail")
+	}
+}
+
+func BenchmarkMsgEncodeJSON(b *testing.B) {
+	msg := newTestMsg()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf, _ := json.Marshal(msg)
+		b.SetBytes(int64(len(buf)))
+	}
+}
+
+func BenchmarkMsgEncodeBinary(b *testing.B) {
+	msg := newTestMsg()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf, _ := MsgEncode(msg)
+		b.SetBytes(int64(len(buf)))
+	}
+}
+
+func BenchmarkMsgDecodeJSON(b *testing.B) {
+	buf, _ := json.Marshal(newTestMsg())
+	b.SetBytes(int64(len(buf)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var msg Msg
+		_ = json.Unmarshal(buf, &msg)
+	}
+}
+
+func BenchmarkMsgDecodeBinary(b *testing.B) {
+	buf, _ := MsgEncode(newTestMsg())
+	b.SetBytes(int64(len(buf)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = MsgDecode(buf)
+	}
+}
@@ -0,0 +1,145 @@
+package remote
+
+import (
+	"common/logs"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPushQueueSize = 1024
+	pushTimeout          = 3 * time.Second
+)
+
+var (
+	ErrDispatcherClosed = errors.New("push dispatcher closed")
+	ErrPushQueueFull    = errors.New("push queue full")
+)
+
+// PushDispatcher 节点上所有session共享的推送分发器
+// 每个目标服务器(connector)一个有界队列和一个发送协程，保证同一目标的消息按顺序送达
+// 队列满时最多阻塞pushTimeout，之后丢弃消息，避免业务协程被永久卡住
+// 阻塞等待时不持有锁，一个目标的队列满了不影响其他目标
+type PushDispatcher struct {
+	sync.RWMutex
+	client    Client
+	queueSize int
+	queues    map[string]chan *Msg
+	wg        sync.WaitGroup
+	closed    bool
+	done      chan struct{} //Close时关闭 队列不关闭，避免向已关闭的通道发送
+}
+
+func NewPushDispatcher(client Client, queueSize int) *PushDispatcher {
+	if queueSize <= 0 {
+		queueSize = defaultPushQueueSize
+	}
+	return &PushDispatcher{
+		client:    client,
+		queueSize: queueSize,
+		queues:    make(map[string]chan *Msg),
+		done:      make(chan struct{}),
+	}
+}
+
+// Dispatch 将消息放入目标服务器的队列
+func (d *PushDispatcher) Dispatch(msg *Msg) error {
+	queue := d.queue(msg.Dst)
+	if queue == nil {
+		return ErrDispatcherClosed
+	}
+	select {
+	case <-d.done:
+		return ErrDispatcherClosed
+	case queue <- msg:
+		return nil
+	default:
+	}
+	timer := time.NewTimer(pushTimeout)
+	defer timer.Stop()
+	select {
+	case <-d.done:
+		return ErrDispatcherClosed
+	case queue <- msg:
+		return nil
+	case <-timer.C:
+		logs.Error("push queue full, drop msg,dst=%s,uid=%s", msg.Dst, msg.Uid)
+		return ErrPushQueueFull
+	}
+}
+
+// queue 查找或者创建目标服务器的队列 已关闭返回nil
+func (d *PushDispatcher) queue(dst string) chan *Msg {
+	d.RLock()
+	queue, ok := d.queues[dst]
+	closed := d.closed
+	d.RUnlock()
+	if closed {
+		return nil
+	}
+	if ok {
+		return queue
+	}
+	return d.createQueue(dst)
+}
+
+func (d *PushDispatcher) createQueue(dst string) chan *Msg {
+	d.Lock()
+	defer d.Unlock()
+	if d.closed {
+		return nil
+	}
+	if queue, ok := d.queues[dst]; ok {
+		return queue
+	}
+	queue := make(chan *Msg, d.queueSize)
+	d.queues[dst] = queue
+	d.wg.Add(1)
+	go d.send(dst, queue)
+	return queue
+}
+
+func (d *PushDispatcher) send(dst string, queue chan *Msg) {
+	defer d.wg.Done()
+	for {
+		select {
+		case msg := <-queue:
+			d.sendMsg(dst, msg)
+		case <-d.done:
+			//关闭之后把已经入队的消息发送完
+			for {
+				select {
+				case msg := <-queue:
+					d.sendMsg(dst, msg)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (d *PushDispatcher) sendMsg(dst string, msg *Msg) {
+	data, err := MsgEncode(msg)
+	if err != nil {
+		logs.Error("encode push msg err:%v,dst=%s", err, dst)
+		return
+	}
+	if err := d.client.SendMsg(dst, data); err != nil {
+		logs.Error("send push msg err:%v,dst=%s,uid=%s", err, dst, msg.Uid)
+	}
+}
+
+// Close 停止接收新消息，等待已入队的消息全部发送完成
+func (d *PushDispatcher) Close() {
+	d.Lock()
+	if d.closed {
+		d.Unlock()
+		return
+	}
+	d.closed = true
+	close(d.done)
+	d.Unlock()
+	d.wg.Wait()
+}
@@ -0,0 +1,72 @@
+package remote
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingClient 发往blockDst的消息一直阻塞到release被关闭
+type blockingClient struct {
+	mu       sync.Mutex
+	blockDst string
+	release  chan struct{}
+	received map[string]int
+}
+
+func (c *blockingClient) Run() error   { return nil }
+func (c *blockingClient) Close() error { return nil }
+func (c *blockingClient) SendMsg(dst string, data []byte) error {
+	if dst == c.blockDst {
+		<-c.release
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.received[dst]++
+	return nil
+}
+
+func (c *blockingClient) count(dst string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.received[dst]
+}
+
+func TestDispatchFullQueueDoesNotBlockOthers(t *testing.T) {
+	client := &blockingClient{blockDst: "slow", release: make(chan struct{}), received: make(map[string]int)}
+	d := NewPushDispatcher(client, 1)
+	//第一条被发送协程取走并阻塞 第二条占满队列
+	for i := 0; i < 2; i++ {
+		if err := d.Dispatch(&Msg{Dst: "slow"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+	blocked := make(chan error, 1)
+	go func() {
+		blocked <- d.Dispatch(&Msg{Dst: "slow"})
+	}()
+	time.Sleep(50 * time.Millisecond)
+	//创建新目标的队列需要写锁 不能被阻塞中的Dispatch卡住
+	start := time.Now()
+	if err := d.Dispatch(&Msg{Dst: "fast"}); err != nil {
+		t.Fatal(err)
+	}
+	for client.count("fast") == 0 {
+		if time.Since(start) > time.Second {
+			t.Fatal("fast destination stalled by the full slow queue")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(client.release)
+	if err := <-blocked; err != nil {
+		t.Fatalf("blocked dispatch should succeed after release, got %v", err)
+	}
+	d.Close()
+	if got := client.count("slow"); got != 3 {
+		t.Fatalf("slow received %d, want 3", got)
+	}
+	if err := d.Dispatch(&Msg{Dst: "fast"}); err != ErrDispatcherClosed {
+		t.Fatalf("dispatch after close should fail, got %v", err)
+	}
+}
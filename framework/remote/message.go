@@ -12,6 +12,18 @@ type Msg struct {
 	SessionData map[string]any
 	Type        int // 0 normal 1 session
 	PushUser    []string
+	TraceId     string // connector收到客户端消息时生成，跨服务透传
 }
 
-const SessionType = 1
+const (
+	SessionType = 1
+	// DrainType 发给节点时表示进入drain状态，节点广播时通知connector不再分配新请求
+	DrainType = 2
+	// ReadyType 节点启动后广播，connector恢复分配请求
+	ReadyType = 3
+	// CloseType 客户端连接断开，connector通知session绑定的节点
+	CloseType = 4
+)
+
+// CloseRouter 节点注册该路由处理客户端断开 例如房间标记用户掉线
+const CloseRouter = "session.close"
@@ -2,10 +2,14 @@ package remote
 
 import (
 	"common/logs"
+	"common/metrics"
 	"framework/game"
 	"github.com/nats-io/nats.go"
+	"time"
 )
 
+const defaultReconnectWait = 2 * time.Second
+
 type NatsClient struct {
 	serverId string
 	conn     *nats.Conn
@@ -20,17 +24,75 @@ func NewNatClient(serverId string, readChan chan []byte) *NatsClient {
 
 }
 
+// StateSubject 节点状态广播主题 只有connector订阅
+const StateSubject = "server.state"
+
+// QueueSubject 同类型服务器共享的订阅主题
+func QueueSubject(serverType string) string {
+	return "queue." + serverType
+}
+
 func (c *NatsClient) Run() error {
 	var err error
-	c.conn, err = nats.Connect(game.Conf.ServersConf.Nats.Url)
+	natsConf := game.Conf.ServersConf().Nats
+	//启动时nats暂时不可用 不直接失败 后台重连
+	c.conn, err = nats.Connect(natsConf.Url, c.options(natsConf)...)
 	if err != nil {
 		logs.Error("Nats connect err:%v", err)
 		return err
 	}
-	go c.sub()
-	return nil
+	if !c.conn.IsConnected() {
+		logs.Warn("Nats not connected yet,retry in background,url=%s", natsConf.Url)
+	}
+	return c.sub()
 
 }
+
+func (c *NatsClient) options(conf game.NatsConfig) []nats.Option {
+	reconnectWait := defaultReconnectWait
+	if conf.ReconnectWait > 0 {
+		reconnectWait = time.Duration(conf.ReconnectWait) * time.Second
+	}
+	maxReconnects := -1
+	if conf.MaxReconnects > 0 {
+		maxReconnects = conf.MaxReconnects
+	}
+	opts := []nats.Option{
+		nats.Name(c.serverId),
+		nats.RetryOnFailedConnect(true),
+		nats.ReconnectWait(reconnectWait),
+		nats.MaxReconnects(maxReconnects),
+		nats.ConnectHandler(func(conn *nats.Conn) {
+			metrics.NatsEvents.WithLabelValues("connect").Inc()
+			logs.Info("Nats connected,server=%s", conn.ConnectedUrl())
+		}),
+		nats.DisconnectErrHandler(func(conn *nats.Conn, err error) {
+			metrics.NatsEvents.WithLabelValues("disconnect").Inc()
+			logs.Warn("Nats disconnected,err:%v", err)
+		}),
+		nats.ReconnectHandler(func(conn *nats.Conn) {
+			metrics.NatsEvents.WithLabelValues("reconnect").Inc()
+			logs.Info("Nats reconnected,server=%s", conn.ConnectedUrl())
+		}),
+		nats.ClosedHandler(func(conn *nats.Conn) {
+			metrics.NatsEvents.WithLabelValues("closed").Inc()
+			logs.Info("Nats connection closed")
+		}),
+		nats.ErrorHandler(func(conn *nats.Conn, sub *nats.Subscription, err error) {
+			metrics.NatsEvents.WithLabelValues("error").Inc()
+			if sub != nil {
+				logs.Error("Nats async err:%v,subject=%s", err, sub.Subject)
+				return
+			}
+			logs.Error("Nats async err:%v", err)
+		}),
+	}
+	if conf.ReconnectBufSize > 0 {
+		opts = append(opts, nats.ReconnectBufSize(conf.ReconnectBufSize))
+	}
+	return opts
+}
+
 func (c *NatsClient) Close() error {
 	if c.conn != nil {
 		c.conn.Close()
@@ -40,20 +102,43 @@ func (c *NatsClient) Close() error {
 }
 func (c *NatsClient) SendMsg(dst string, data []byte) error {
 	if c.conn != nil {
-		return c.conn.Publish(dst, data)
+		err := c.conn.Publish(dst, data)
+		if err != nil {
+			metrics.NatsEvents.WithLabelValues("publishError").Inc()
+		}
+		return err
 
 	}
 
 	return nil
 
 }
-func (c *NatsClient) sub() {
-	_, err := c.conn.Subscribe(c.serverId, func(msg *nats.Msg) {
+func (c *NatsClient) sub() error {
+	handler := func(msg *nats.Msg) {
 		//收到其他nat发送的消息
 		c.readChan <- msg.Data
-	})
+	}
+	_, err := c.conn.Subscribe(c.serverId, handler)
 	if err != nil {
 		logs.Error("Nats subscribe err:%v", err)
+		return err
+	}
+	if game.Conf.GetConnector(c.serverId) != nil {
+		if _, err = c.conn.Subscribe(StateSubject, handler); err != nil {
+			logs.Error("Nats subscribe err:%v,subject=%s", err, StateSubject)
+			return err
+		}
 	}
+	serverConf := game.Conf.GetServer(c.serverId)
+	if serverConf != nil && serverConf.Queue {
+		subject := QueueSubject(serverConf.ServerType)
+		_, err = c.conn.QueueSubscribe(subject, serverConf.ServerType, handler)
+		if err != nil {
+			logs.Error("Nats queue subscribe err:%v,subject=%s", err, subject)
+			return err
+		}
+		logs.Info("Nats queue subscribe,subject=%s", subject)
+	}
+	return nil
 
 }
@@ -7,114 +7,124 @@ import (
 	"sync"
 )
 
+// Session 每条远端消息对应一个session，本身不持有协程
+// push和session数据同步都交给节点共享的PushDispatcher，按目标connector顺序发送
 type Session struct {
 	sync.RWMutex
-	client          Client
-	msg             *Msg
-	pushChan        chan *userPushMsg
-	data            map[string]any
-	pushSessionChan chan map[string]any
-}
-type pushMsg struct {
-	data   []byte
-	router string
-}
-type userPushMsg struct {
-	PushMsg pushMsg  `json:"pushMsg"`
-	Users   []string `json:"users"`
-}
-
-func NewSession(client Client, msg *Msg) *Session {
-	s := &Session{
-		client:          client,
-		msg:             msg,
-		pushChan:        make(chan *userPushMsg, 1024),
-		data:            make(map[string]any),
-		pushSessionChan: make(chan map[string]any, 1024),
-	}
-	go s.pushChanRead()
-	go s.pushSessionChanRead()
-	return s
+	dispatcher *PushDispatcher
+	msg        *Msg
+	data       map[string]any
+}
 
+func NewSession(dispatcher *PushDispatcher, msg *Msg) *Session {
+	return &Session{
+		dispatcher: dispatcher,
+		msg:        msg,
+		data:       make(map[string]any),
+	}
 }
+
 func (s *Session) GetUid() string {
 	return s.msg.Uid
 
 }
 
+// TraceId connector生成的traceId，push和session数据同步沿用同一个
+func (s *Session) TraceId() string {
+	return s.msg.TraceId
+}
+
+// Log 带traceId和uid的日志
+func (s *Session) Log() *logs.Logger {
+	return logs.WithTrace(s.msg.TraceId).With(logs.KeyUid, s.msg.Uid)
+}
+
 func (s *Session) Push(users []string, data any, router string) {
-	msg, _ := json.Marshal(data)
-	pm := pushMsg{
-		data:   msg,
-		router: router,
+	s.PushTo(s.msg.Src, users, data, router)
+}
+
+// PushTo 推送给指定connector上的用户 用户不在发起请求的connector上时使用
+func (s *Session) PushTo(connectorId string, users []string, data any, router string) {
+	msg, err := json.Marshal(data)
+	if err != nil {
+		s.Log().Error("push data marshal err:%v", err)
+		return
 	}
-	upm := &userPushMsg{
-		PushMsg: pm,
-		Users:   users,
+	var id uint
+	if s.msg.Body != nil {
+		id = s.msg.Body.ID
 	}
-	s.pushChan <- upm
-
-}
-
-func (s *Session) pushChanRead() {
-	for {
-		select {
-		case data := <-s.pushChan:
-			pushMessage := protocol.Message{
-				Type:  protocol.Push,
-				ID:    s.msg.Body.ID,
-				Route: data.PushMsg.router,
-				Data:  data.PushMsg.data,
-			}
-			msg := Msg{
-				Dst:      s.msg.Src,
-				Src:      s.msg.Dst,
-				Body:     &pushMessage,
-				Cid:      s.msg.Cid,
-				Uid:      s.msg.Uid,
-				PushUser: data.Users,
-			}
-			result, _ := json.Marshal(msg)
-			err := s.client.SendMsg(msg.Dst, result)
-			if err != nil {
-				logs.Error("send push msg err:%v,msg=%v", err, msg)
-				return
-			}
-
-		}
+	pushMessage := protocol.Message{
+		Type:  protocol.Push,
+		ID:    id,
+		Route: router,
+		Data:  msg,
 	}
+	err = s.dispatcher.Dispatch(&Msg{
+		Dst:      connectorId,
+		Src:      s.msg.Dst,
+		Body:     &pushMessage,
+		Cid:      s.msg.Cid,
+		Uid:      s.msg.Uid,
+		PushUser: users,
+		TraceId:  s.msg.TraceId,
+	})
+	if err != nil {
+		s.Log().Error("send push msg err:%v,router=%s", err, router)
+	}
+}
 
+// Cid 用户在connector上的连接id 重连之后会变化
+func (s *Session) Cid() string {
+	return s.msg.Cid
+}
+
+// ConnectorId 用户所在的connector
+func (s *Session) ConnectorId() string {
+	return s.msg.Src
+}
+
+// ServerId 处理当前消息的节点
+func (s *Session) ServerId() string {
+	return s.msg.Dst
+}
+
+// BindServer 之后该类型的请求都路由到当前节点，有状态的服务(例如房间所在的game节点)使用
+func (s *Session) BindServer(serverType string) {
+	s.Put(ServerKey(serverType), s.msg.Dst)
+}
+
+// UnbindServer 解除绑定 之后该类型的请求重新选择节点
+func (s *Session) UnbindServer(serverType string) {
+	s.Put(ServerKey(serverType), "")
+}
+
+// ServerKey session中保存绑定节点的key
+func ServerKey(serverType string) string {
+	return serverType + "ServerId"
 }
 
 func (s *Session) Put(key string, value any) {
 	s.Lock()
-	defer s.Unlock()
 	s.data[key] = value
-	s.pushSessionChan <- s.data
-}
-
-func (s *Session) pushSessionChanRead() {
-	for {
-		select {
-		case data := <-s.pushSessionChan:
-			msg := Msg{
-				Dst: s.msg.Src,
-				Src: s.msg.Dst,
-
-				Cid:         s.msg.Cid,
-				Uid:         s.msg.Uid,
-				SessionData: data,
-				Type:        SessionType,
-			}
-			res, _ := json.Marshal(msg)
-			err := s.client.SendMsg(msg.Dst, res)
-			if err != nil {
-				logs.Error(" push session data err:%v", err)
-			}
-
-		}
+	//拷贝一份，发送的时候data可能已经被修改
+	data := make(map[string]any, len(s.data))
+	for k, v := range s.data {
+		data[k] = v
+	}
+	s.Unlock()
+	err := s.dispatcher.Dispatch(&Msg{
+		Dst:         s.msg.Src,
+		Src:         s.msg.Dst,
+		Cid:         s.msg.Cid,
+		Uid:         s.msg.Uid,
+		SessionData: data,
+		Type:        SessionType,
+		TraceId:     s.msg.TraceId,
+	})
+	if err != nil {
+		s.Log().Error(" push session data err:%v", err)
 	}
-
 }
 
 func (s *Session) SetData(data map[string]any) {
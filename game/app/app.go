@@ -5,7 +5,9 @@ import (
 	"common/logs"
 	"context"
 	"core/repo"
+	"framework/game"
 	"framework/node"
+	"game/logic"
 	"game/route"
 	"os"
 	"os/signal"
@@ -15,22 +17,42 @@ import (
 
 func Run(ctx context.Context, serverId string) error {
 	//日志
-	logs.InitLog(config.Conf.AppName)
-	exit := func() {}
+	logs.InitLog(config.Conf().AppName)
+	n := node.Default()
+	manager := repo.New()
+	um := logic.NewUnionManager(serverId, manager)
 	go func() {
-		n := node.Default()
-		exit = n.Close
-		manager := repo.New()
-		n.RegisterHandler(route.RegisterHandler(manager))
-		n.Run(serverId)
+		n.RegisterHandler(route.RegisterHandler(manager, um))
+		if err := n.Run(serverId); err != nil {
+			logs.Fatal("run game node err:%v", err)
+		}
+		//恢复上次退出或者崩溃之前的房间
+		restoreCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		logs.Info("restore rooms=%d", um.RestoreRooms(restoreCtx, n.NewSession))
 	}()
 
 	stop := func() {
-		exit()
+		n.Close()
 		time.Sleep(3 * time.Second)
 		logs.Info("stop app finish")
 	}
-	//优雅启停
+	//不再创建新房间 等已有房间打完当前这局，超时之后保存剩余的房间
+	drain := func() {
+		n.Drain()
+		um.Drain()
+		timeout := 0
+		if conf := game.Conf.GetServer(serverId); conf != nil {
+			timeout = conf.DrainTimeout
+		}
+		drainCtx, cancel := context.WithTimeout(ctx, game.DrainTimeout(timeout))
+		defer cancel()
+		if !um.WaitRooms(drainCtx) {
+			logs.Warn("suspend remaining rooms=%d", um.SuspendRooms())
+		}
+		stop()
+	}
+	//优雅启停 SIGTERM先drain再退出
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, syscall.SIGTERM, syscall.SIGQUIT, syscall.SIGINT, syscall.SIGHUP)
 	for {
@@ -38,15 +60,23 @@ func Run(ctx context.Context, serverId string) error {
 		case <-ctx.Done():
 			stop()
 			return nil
+		case <-n.Draining():
+			drain()
+			logs.Info("game app drained")
+			return nil
 		case s := <-c:
 			switch s {
-			case syscall.SIGTERM, syscall.SIGQUIT, syscall.SIGINT:
+			case syscall.SIGTERM:
+				drain()
+				logs.Info("game app drained")
+				return nil
+			case syscall.SIGQUIT, syscall.SIGINT:
 				stop()
-				logs.Info("connector app quit")
+				logs.Info("game app quit")
 				return nil
 			case syscall.SIGHUP:
 				stop()
-				logs.Info("connector app reload")
+				logs.Info("game app reload")
 				return nil
 			default:
 				return nil
@@ -3,11 +3,20 @@ package base
 import (
 	"framework/remote"
 	"game/component/proto"
+	"time"
 )
 
 type RoomFrame interface {
 	GetUsers() map[string]*proto.RoomUser
 	GetId() string
-	EndGame(session *remote.Session)
+	// EndGame 一局结束 scores为按座次的得分，局数打完之后房间推送总结算并解散
+	EndGame(session *remote.Session, scores []int)
 	UserReady(uid string, session *remote.Session)
+	AfterFunc(d time.Duration, f func()) *Timer
+	// GetWatchers 观战的用户 只推送公开的消息，手牌都是暗牌
+	GetWatchers() []string
+	// ServerMessagePush 房间按用户所在的connector推送
+	ServerMessagePush(users []string, data any, session *remote.Session)
+	// UserChat 游戏内的聊天消息交给房间统一处理
+	UserChat(session *remote.Session, chatType proto.ChatType, msg string, recipientID int)
 }
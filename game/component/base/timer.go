@@ -0,0 +1,38 @@
+package base
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Timer 房间定时器 回调会投递到房间的消息队列中执行
+// Stop或Reset之后，已经触发但还在队列中等待的回调不会再执行
+type Timer struct {
+	t   *time.Timer
+	gen atomic.Uint64
+}
+
+// NewTimer post负责将回调投递到房间协程
+func NewTimer(d time.Duration, post func(func()), f func()) *Timer {
+	timer := &Timer{}
+	timer.t = time.AfterFunc(d, func() {
+		gen := timer.gen.Load()
+		post(func() {
+			if timer.gen.Load() != gen {
+				return
+			}
+			f()
+		})
+	})
+	return timer
+}
+
+func (t *Timer) Stop() bool {
+	t.gen.Add(1)
+	return t.t.Stop()
+}
+
+func (t *Timer) Reset(d time.Duration) bool {
+	t.gen.Add(1)
+	return t.t.Reset(d)
+}
@@ -2,4 +2,6 @@ package base
 
 type UnionBase interface {
 	DismissRoom(roomId string)
+	// Draining 节点正在drain 房间打完当前这局之后解散
+	Draining() bool
 }
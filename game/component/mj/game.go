@@ -21,28 +21,22 @@ type GameFrame struct {
 	gameData      *GameData
 	logic         *Logic
 	testCardArray []mp.CardID
-	turnSchedule  []*time.Timer
+	turnSchedule  []*base.Timer
+	turnCards     []mp.CardID //定时自动操作使用的牌
+	turnActive    []bool      //座次是否有进行中的操作倒计时
 }
 
 func (g *GameFrame) GetGameData(session *remote.Session) any {
 	//获取场景 获取游戏的数据
 	//mj 打牌的时候 别人的牌 不能看到
-	chairID := g.r.GetUsers()[session.GetUid()].ChairID
+	//观战的用户 所有人的牌都是暗牌
+	chairID := -1
+	if user, ok := g.r.GetUsers()[session.GetUid()]; ok {
+		chairID = user.ChairID
+	}
 	var gameData GameData
 	copier.CopyWithOption(&gameData, g.gameData, copier.Option{IgnoreEmpty: true, DeepCopy: true})
-	handCards := make([][]mp.CardID, g.gameData.ChairCount)
-	for i := range gameData.HandCards {
-		if i == chairID {
-			handCards[i] = gameData.HandCards[i]
-		} else {
-			//每张牌 置为 36
-			handCards[i] = make([]mp.CardID, len(g.gameData.HandCards[i]))
-			for j := range g.gameData.HandCards[i] {
-				handCards[i][j] = 36
-			}
-		}
-	}
-	gameData.HandCards = handCards
+	gameData.HandCards = g.maskHandCards(chairID)
 	if g.gameData.GameStatus == GameStatusNone {
 		gameData.RestCardsCount = 9*3*4 + 4
 		if g.gameRule.GameFrameType == HongZhong8 {
@@ -95,10 +89,35 @@ func (g *GameFrame) sendDataUsers(users []string, data any, session *remote.Sess
 }
 
 func (g *GameFrame) sendData(data any, session *remote.Session) {
-	g.ServerMessagePush(g.getAllUsers(), data, session)
+	g.ServerMessagePush(append(g.getAllUsers(), g.r.GetWatchers()...), data, session)
+}
+
+// sendWatchers 只推送给观战的用户 玩家收到的是各自的私有数据
+func (g *GameFrame) sendWatchers(data any, session *remote.Session) {
+	watchers := g.r.GetWatchers()
+	if len(watchers) == 0 {
+		return
+	}
+	g.ServerMessagePush(watchers, data, session)
+}
+
+// maskHandCards chairID的手牌是明牌 其他人的每张牌置为36，chairID为-1时全部是暗牌
+func (g *GameFrame) maskHandCards(chairID int) [][]mp.CardID {
+	handCards := make([][]mp.CardID, g.gameData.ChairCount)
+	for i := range g.gameData.HandCards {
+		if i == chairID {
+			handCards[i] = append([]mp.CardID(nil), g.gameData.HandCards[i]...)
+		} else {
+			handCards[i] = make([]mp.CardID, len(g.gameData.HandCards[i]))
+			for j := range g.gameData.HandCards[i] {
+				handCards[i][j] = 36
+			}
+		}
+	}
+	return handCards
 }
 func (g *GameFrame) ServerMessagePush(users []string, data any, session *remote.Session) {
-	session.Push(users, data, "ServerMessagePush")
+	g.r.ServerMessagePush(users, data, session)
 }
 func (g *GameFrame) getAllUsers() []string {
 	users := make([]string, 0)
@@ -121,36 +140,30 @@ func (g *GameFrame) sendHandCards(session *remote.Session) {
 		//}
 	}
 	for i := 0; i < g.gameData.ChairCount; i++ {
-		handCards := make([][]mp.CardID, g.gameData.ChairCount)
-		for j := 0; j < g.gameData.ChairCount; j++ {
-			if i == j {
-				handCards[i] = g.gameData.HandCards[i]
-			} else {
-				handCards[j] = make([]mp.CardID, len(g.gameData.HandCards[j]))
-				for k := range g.gameData.HandCards[j] {
-					handCards[j][k] = 36
-				}
-			}
-		}
 		//推送牌
 		uid := g.getUserByChairID(i).UserInfo.Uid
-		g.sendDataUsers([]string{uid}, GameSendCardsPushData(handCards, i), session)
+		g.sendDataUsers([]string{uid}, GameSendCardsPushData(g.maskHandCards(i), i), session)
 	}
+	g.sendWatchers(GameWatchSendCardsPushData(g.maskHandCards(-1)), session)
 
 	//5. 剩余牌数推送
 	restCardsCount := g.logic.getRestCardsCount()
 	fmt.Println(1111, restCardsCount)
 	g.sendData(GameRestCardsCountPushData(restCardsCount), session)
-	time.AfterFunc(time.Second, func() {
-		//7. 开始游戏状态推送
-		g.gameData.GameStatus = Playing
-		g.sendData(GameStatusPushData(g.gameData.GameStatus, GameStatusTmPlay), session)
-		//玩家的操作时间了
-		g.setTurn(g.gameData.BankerChairID, session)
+	g.r.AfterFunc(time.Second, func() {
+		g.startPlaying(session)
 	})
 
 }
 
+func (g *GameFrame) startPlaying(session *remote.Session) {
+	//7. 开始游戏状态推送
+	g.gameData.GameStatus = Playing
+	g.sendData(GameStatusPushData(g.gameData.GameStatus, GameStatusTmPlay), session)
+	//玩家的操作时间了
+	g.setTurn(g.gameData.BankerChairID, session)
+}
+
 func (g *GameFrame) getUserByChairID(chairID int) *proto.RoomUser {
 	for _, v := range g.r.GetUsers() {
 		if v.ChairID == chairID {
@@ -160,12 +173,17 @@ func (g *GameFrame) getUserByChairID(chairID int) *proto.RoomUser {
 	return nil
 }
 
+// log 带traceId uid roomId的日志
+func (g *GameFrame) log(session *remote.Session) *logs.Logger {
+	return session.Log().With(logs.KeyRoomId, g.r.GetId())
+}
+
 func (g *GameFrame) setTurn(chairID int, session *remote.Session) {
 	//8. 拿牌推送
 	g.gameData.CurChairID = chairID
 	//牌不能大于14
 	if len(g.gameData.HandCards[chairID]) >= 14 {
-		logs.Warn("已经拿过牌了,chairID:%d", chairID)
+		g.log(session).Warn("已经拿过牌了,chairID:%d", chairID)
 		return
 	}
 	card := g.testCardArray[chairID]
@@ -205,6 +223,7 @@ func (g *GameFrame) setTurn(chairID int, session *remote.Session) {
 		//只能进行一次触发
 
 	}
+	g.sendWatchers(GameTurnPushData(chairID, 36, OperateTime, nil), session)
 	//9. 剩余牌数推送
 	restCardsCount := g.logic.getRestCardsCount()
 	g.sendData(GameRestCardsCountPushData(restCardsCount), session)
@@ -220,15 +239,13 @@ func (g *GameFrame) gameTurn(uids []string, chairID int, card mp.CardID, operate
 	}
 }
 func (g *GameFrame) turnScheduleExecute(chairID int, card mp.CardID, operateArray []OperateType, session *remote.Session) {
-	if g.turnSchedule[chairID] != nil {
-		g.turnSchedule[chairID].Stop()
-	}
-	g.turnSchedule[chairID] = time.AfterFunc(time.Second, func() {
+	g.stopTurn(chairID)
+	g.turnCards[chairID] = card
+	g.turnActive[chairID] = true
+	g.turnSchedule[chairID] = g.r.AfterFunc(time.Second, func() {
 		if g.gameData.Tick <= 0 {
 			//取消定时
-			if g.turnSchedule[chairID] != nil {
-				g.turnSchedule[chairID].Stop()
-			}
+			g.stopTurn(chairID)
 			g.userAutoOperate(chairID, card, operateArray, session)
 		} else {
 			g.gameData.Tick--
@@ -236,6 +253,13 @@ func (g *GameFrame) turnScheduleExecute(chairID int, card mp.CardID, operateArra
 		}
 	})
 }
+func (g *GameFrame) stopTurn(chairID int) {
+	if g.turnSchedule[chairID] != nil {
+		g.turnSchedule[chairID].Stop()
+	}
+	g.turnActive[chairID] = false
+}
+
 func (g *GameFrame) getMyOperateArray(session *remote.Session, chairID int, card mp.CardID) []OperateType {
 	//需要获取用户可操作的行为 ，比如 弃牌 碰牌 杠牌 胡牌
 	var operateArray = []OperateType{Qi}
@@ -263,13 +287,11 @@ func (g *GameFrame) getMyOperateArray(session *remote.Session, chairID int, card
 }
 
 func (g *GameFrame) onGameChat(user *proto.RoomUser, session *remote.Session, data MessageData) {
-	g.sendData(GameChatPushData(user.ChairID, data.Type, data.Msg, data.RecipientID), session)
+	g.r.UserChat(session, proto.ChatType(data.Type), data.Msg, data.RecipientID)
 }
 
 func (g *GameFrame) onGameTurnOperate(user *proto.RoomUser, session *remote.Session, data MessageData) {
-	if g.turnSchedule[user.ChairID] != nil {
-		g.turnSchedule[user.ChairID].Stop()
-	}
+	g.stopTurn(user.ChairID)
 	if data.Operate == Qi {
 		//1. 向所有人通告 当前用户做了什么操作
 		g.sendData(GameTurnOperatePushData(user.ChairID, data.Card, data.Operate, true), session)
@@ -294,7 +316,7 @@ func (g *GameFrame) onGameTurnOperate(user *proto.RoomUser, session *remote.Sess
 			length := len(g.gameData.OperateRecord)
 			if length == 0 {
 				//没有记录 出错了
-				logs.Error("用户碰操作，但是没有上一个操作记录")
+				g.log(session).Error("用户碰操作，但是没有上一个操作记录,chairID:%d", user.ChairID)
 			} else {
 				data.Card = g.gameData.OperateRecord[length-1].Card
 			}
@@ -315,7 +337,7 @@ func (g *GameFrame) onGameTurnOperate(user *proto.RoomUser, session *remote.Sess
 			length := len(g.gameData.OperateRecord)
 			if length == 0 {
 				//没有记录 出错了
-				logs.Error("用户吃杠操作，但是没有上一个操作记录")
+				g.log(session).Error("用户吃杠操作，但是没有上一个操作记录,chairID:%d", user.ChairID)
 			} else {
 				data.Card = g.gameData.OperateRecord[length-1].Card
 			}
@@ -334,7 +356,7 @@ func (g *GameFrame) onGameTurnOperate(user *proto.RoomUser, session *remote.Sess
 			length := len(g.gameData.OperateRecord)
 			if length == 0 {
 				//没有记录 出错了
-				logs.Error("用户吃胡操作，但是没有上一个操作记录")
+				g.log(session).Error("用户吃胡操作，但是没有上一个操作记录,chairID:%d", user.ChairID)
 			} else {
 				data.Card = g.gameData.OperateRecord[length-1].Card
 			}
@@ -366,6 +388,7 @@ func (g *GameFrame) onGameTurnOperate(user *proto.RoomUser, session *remote.Sess
 				g.sendDataUsers([]string{g.getUserByChairID(i).UserInfo.Uid}, GameTurnOperatePushData(user.ChairID, data.Card, data.Operate, true), session)
 			}
 		}
+		g.sendWatchers(GameTurnOperatePushData(user.ChairID, data.Card, data.Operate, true), session)
 		g.gameData.HandCards[user.ChairID] = g.delCards(g.gameData.HandCards[user.ChairID], card, 4)
 
 		g.gameData.OperateRecord = append(g.gameData.OperateRecord, OperateRecord{user.ChairID, card, data.Operate})
@@ -382,6 +405,7 @@ func (g *GameFrame) onGameTurnOperate(user *proto.RoomUser, session *remote.Sess
 					g.sendDataUsers([]string{g.getUserByChairID(i).UserInfo.Uid}, GameTurnOperatePushData(user.ChairID, data.Card, data.Operate, true), session)
 				}
 			}
+			g.sendWatchers(GameTurnOperatePushData(user.ChairID, data.Card, data.Operate, true), session)
 			g.gameData.HandCards[user.ChairID] = g.delCards(g.gameData.HandCards[user.ChairID], card, 1)
 
 			g.gameData.OperateRecord = append(g.gameData.OperateRecord, OperateRecord{user.ChairID, card, data.Operate})
@@ -470,23 +494,18 @@ func (g *GameFrame) nextTurn(lastCard mp.CardID, session *remote.Session) {
 func (g *GameFrame) gameEnd(operate OperateType, session *remote.Session) {
 	g.gameData.GameStatus = Result
 	g.sendData(GameStatusPushData(g.gameData.GameStatus, 0), session)
-	scores := make([]int, g.gameData.ChairCount)
-	//结算推送
-	//for i := 0; i < g.gameData.ChairCount; i++ {
-	//
-	//}
 	l := len(g.gameData.OperateRecord)
 	if l <= 0 {
-		logs.Error("没有操作记录，不可能游戏结束，请检查")
+		g.log(session).Error("没有操作记录，不可能游戏结束，请检查")
 		return
 	}
 	lastOperateRecord := g.gameData.OperateRecord[l-1]
 	if lastOperateRecord.Operate != HuChi && lastOperateRecord.Operate != HuZi {
-		logs.Error("最后一次操作，不是胡牌，不可能游戏结束，请检查")
+		g.log(session).Error("最后一次操作，不是胡牌，不可能游戏结束，请检查")
 		return
 	}
 	result := GameResult{
-		Scores:          scores,
+		Scores:          g.huScores(l - 1),
 		HandCards:       g.gameData.HandCards,
 		RestCards:       g.logic.getRestCards(),
 		WinChairIDArray: []int{lastOperateRecord.ChairID},
@@ -497,13 +516,52 @@ func (g *GameFrame) gameEnd(operate OperateType, session *remote.Session) {
 	g.gameData.Result = &result
 	g.sendData(GameResultPushData(result), session)
 
-	time.AfterFunc(3*time.Second, func() {
-		g.r.EndGame(session)
-		g.resetGame(session)
+	g.r.AfterFunc(3*time.Second, func() {
+		g.finishGame(session)
 	})
 	//倒计时30秒 如果用户未操作 自动准备或者踢出房间
 }
 
+// huScores 胡牌的得分 自摸其他玩家每人输两倍底分，吃胡点炮的玩家输其他玩家每人一倍底分之和
+func (g *GameFrame) huScores(huIndex int) []int {
+	scores := make([]int, g.gameData.ChairCount)
+	base := g.gameRule.BaseScore
+	if base <= 0 {
+		base = 1
+	}
+	hu := g.gameData.OperateRecord[huIndex]
+	if hu.Operate == HuZi {
+		for _, v := range g.r.GetUsers() {
+			if v.ChairID != hu.ChairID && v.UserStatus&proto.Playing != 0 {
+				scores[v.ChairID] -= 2 * base
+				scores[hu.ChairID] += 2 * base
+			}
+		}
+		return scores
+	}
+	if huIndex == 0 {
+		return scores
+	}
+	//吃胡的牌是上一个操作打出的
+	loser := g.gameData.OperateRecord[huIndex-1].ChairID
+	for _, v := range g.r.GetUsers() {
+		if v.ChairID != hu.ChairID && v.UserStatus&proto.Playing != 0 {
+			scores[loser] -= base
+			scores[hu.ChairID] += base
+		}
+	}
+	return scores
+}
+
+func (g *GameFrame) finishGame(session *remote.Session) {
+	var scores []int
+	if g.gameData.Result != nil {
+		scores = g.gameData.Result.Scores
+	}
+	g.r.EndGame(session, scores)
+	g.resetGame(session)
+}
+
 func (g *GameFrame) resetGame(session *remote.Session) {
 	g.gameData.GameStarted = false
 	g.gameData.GameStatus = GameStatusNone
@@ -552,13 +610,16 @@ func NewGameFrame(rule proto.GameRule, r base.RoomFrame) *GameFrame {
 		gameData:      gameData,
 		logic:         NewLogic(GameType(rule.GameFrameType), rule.Qidui),
 		testCardArray: make([]mp.CardID, gameData.ChairCount),
-		turnSchedule:  make([]*time.Timer, gameData.ChairCount),
+		turnSchedule:  make([]*base.Timer, gameData.ChairCount),
+		turnCards:     make([]mp.CardID, gameData.ChairCount),
+		turnActive:    make([]bool, gameData.ChairCount),
 	}
 }
 
 func initGameData(rule proto.GameRule) *GameData {
 	g := new(GameData)
 	g.ChairCount = rule.MaxPlayerCount
+	g.MaxBureau = rule.Bureau
 	g.HandCards = make([][]mp.CardID, g.ChairCount)
 	g.GameStatus = GameStatusNone
 	g.OperateRecord = make([]OperateRecord, 0)
@@ -0,0 +1,59 @@
+package mj
+
+import (
+	"game/component/base"
+	"game/component/proto"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// fakeRoom 只实现计算输赢用到的GetUsers
+type fakeRoom struct {
+	base.RoomFrame
+	users map[string]*proto.RoomUser
+}
+
+func (r *fakeRoom) GetUsers() map[string]*proto.RoomUser {
+	return r.users
+}
+
+func newTestFrame(chairCount int, baseScore int, playing ...int) *GameFrame {
+	r := &fakeRoom{users: make(map[string]*proto.RoomUser)}
+	for _, i := range playing {
+		uid := strconv.Itoa(i)
+		r.users[uid] = &proto.RoomUser{UserInfo: proto.UserInfo{Uid: uid}, ChairID: i, UserStatus: proto.Playing}
+	}
+	return NewGameFrame(proto.GameRule{MaxPlayerCount: chairCount, BaseScore: baseScore}, r)
+}
+
+func TestHuScores(t *testing.T) {
+	cases := []struct {
+		name    string
+		base    int
+		playing []int
+		records []OperateRecord
+		want    []int
+	}{
+		{"zimo", 2, []int{0, 1, 2, 3}, []OperateRecord{{ChairID: 1, Operate: HuZi}}, []int{-4, 12, -4, -4}},
+		{"zimo three players", 1, []int{0, 1, 2}, []OperateRecord{{ChairID: 0, Operate: HuZi}}, []int{4, -2, -2, 0}},
+		{"chihu", 2, []int{0, 1, 2, 3}, []OperateRecord{{ChairID: 0, Operate: Qi}, {ChairID: 2, Operate: HuChi}}, []int{-6, 0, 6, 0}},
+		{"zero base score", 0, []int{0, 1, 2, 3}, []OperateRecord{{ChairID: 3, Operate: Qi}, {ChairID: 1, Operate: HuChi}}, []int{0, 3, 0, -3}},
+		{"chihu without discard", 1, []int{0, 1, 2, 3}, []OperateRecord{{ChairID: 1, Operate: HuChi}}, []int{0, 0, 0, 0}},
+	}
+	for _, c := range cases {
+		g := newTestFrame(4, c.base, c.playing...)
+		g.gameData.OperateRecord = c.records
+		got := g.huScores(len(c.records) - 1)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Fatalf("%s: huScores=%v, want %v", c.name, got, c.want)
+		}
+		sum := 0
+		for _, v := range got {
+			sum += v
+		}
+		if sum != 0 {
+			t.Fatalf("%s: huScores=%v is not zero-sum", c.name, got)
+		}
+	}
+}
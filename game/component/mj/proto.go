@@ -120,6 +120,7 @@ const (
 	GameDismissPush        = 414 //解散推送
 	GameGetCardNotify      = 315 //拿牌通知
 	GameGetCardPush        = 415 //拿牌推送
+	GameWatchSendCardsPush = 416 //观战的发牌推送 所有人的牌都是暗牌，没有自己的座次
 )
 
 func GameStatusPushData(gameStatus GameStatus, tick int) any {
@@ -161,6 +162,15 @@ func GameSendCardsPushData(handCards [][]mp.CardID, chairID int) any {
 		"pushRouter": "GameMessagePush",
 	}
 }
+func GameWatchSendCardsPushData(handCards [][]mp.CardID) any {
+	return map[string]any{
+		"type": GameWatchSendCardsPush,
+		"data": map[string]any{
+			"handCards": handCards,
+		},
+		"pushRouter": "GameMessagePush",
+	}
+}
 func GameRestCardsCountPushData(restCardsCount int) any {
 	return map[string]any{
 		"type": GameRestCardsCountPush,
@@ -196,18 +206,6 @@ func GameTurnPushData(chairID int, card mp.CardID, tick int, operateArray []Oper
 		"pushRouter": "GameMessagePush",
 	}
 }
-func GameChatPushData(chairID, t int, msg string, recipientID int) any {
-	return map[string]any{
-		"type": GameChatPush,
-		"data": map[string]any{
-			"chairID":     chairID,
-			"type":        t,
-			"msg":         msg,
-			"recipientID": recipientID,
-		},
-		"pushRouter": "GameMessagePush",
-	}
-}
 func GameTurnOperatePushData(chairID int, card mp.CardID, operate OperateType, success bool) any {
 	var c any
 	if card > 0 && card < 36 {
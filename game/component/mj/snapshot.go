@@ -0,0 +1,66 @@
+package mj
+
+import (
+	"encoding/json"
+	"fmt"
+	"framework/remote"
+	"game/component/mj/mp"
+	"time"
+)
+
+// snapshot 持久化的游戏数据 包括牌堆和进行中的操作倒计时
+type snapshot struct {
+	GameData      *GameData   `json:"gameData"`
+	Cards         []mp.CardID `json:"cards"`
+	TestCardArray []mp.CardID `json:"testCardArray"`
+	TurnCards     []mp.CardID `json:"turnCards"`
+	TurnActive    []bool      `json:"turnActive"`
+}
+
+func (g *GameFrame) Snapshot() ([]byte, error) {
+	g.logic.RLock()
+	defer g.logic.RUnlock()
+	return json.Marshal(&snapshot{
+		GameData:      g.gameData,
+		Cards:         g.logic.cards,
+		TestCardArray: g.testCardArray,
+		TurnCards:     g.turnCards,
+		TurnActive:    g.turnActive,
+	})
+}
+
+func (g *GameFrame) Restore(data []byte, session *remote.Session) error {
+	var s snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s.GameData == nil || s.GameData.ChairCount != g.gameData.ChairCount ||
+		len(s.TurnCards) != s.GameData.ChairCount || len(s.TurnActive) != s.GameData.ChairCount {
+		return fmt.Errorf("mj snapshot chair count mismatch")
+	}
+	g.gameData = s.GameData
+	g.logic.Lock()
+	g.logic.cards = s.Cards
+	g.logic.Unlock()
+	g.testCardArray = s.TestCardArray
+	g.turnCards = s.TurnCards
+	g.turnActive = make([]bool, g.gameData.ChairCount)
+	//定时任务没有保存 根据游戏状态重新启动
+	switch g.gameData.GameStatus {
+	case Dices:
+		g.r.AfterFunc(time.Second, func() {
+			g.startPlaying(session)
+		})
+	case Playing:
+		for i, active := range s.TurnActive {
+			if active {
+				g.turnScheduleExecute(i, g.turnCards[i], g.gameData.OperateArrays[i], session)
+			}
+		}
+	case Result:
+		g.r.AfterFunc(3*time.Second, func() {
+			g.finishGame(session)
+		})
+	}
+	return nil
+}
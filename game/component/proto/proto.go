@@ -1,5 +1,7 @@
 package proto
 
+import "core/models/entity"
+
 type GameRule struct {
 	AddScores      []int `json:"addScores"`      //加注分
 	BaseScore      int   `json:"baseScore"`      //底分 sz hz
@@ -8,13 +10,14 @@ type GameRule struct {
 	CanTrust       bool  `json:"canTrust"`       //允许托管 sz hz
 	Chunniunai     bool  `json:"chunniunai"`     //是否允许搓牛  hz
 	CanWatch       bool  `json:"canWatch"`       //允许观战
+	DismissSettle  bool  `json:"dismissSettle"`  //游戏中途解散 true结算已经完成的局数 false全部作废
 	Cuopai         bool  `json:"cuopai"`         //高级 是否允许搓牌
 	GameFrameType  int   `json:"gameFrameType"`  //游戏模式  sz hz
 	GameType       int   `json:"gameType"`       //游戏类型 牛牛 三公等  sz hz
 	Ma             int   `json:"ma"`             //扎码 hz
 	MaxPlayerCount int   `json:"maxPlayerCount"` //最大人数 sz hz
 	MinPlayerCount int   `json:"minPlayerCount"` //最小人数 sz hz
-	PayDiamond     int   `json:"payDiamond"`     //房费 sz hz
+	PayDiamond     int   `json:"payDiamond"`     //房费 整个房间的费用 sz hz
 	PayType        int   `json:"payType"`        //支付方式 1 AA支付 2 赢家支付 3 我支付 sz hz
 	Qidui          bool  `json:"qidui"`          //七对 一种胡牌方式 hz
 	RoomType       int   `json:"roomType"`       // 1 正常房间 2 持续房间 3 百人房间 hz
@@ -25,6 +28,13 @@ type GameRule struct {
 	RoundType      int   `json:"roundType"`      //轮数 sz
 }
 
+// 房费的支付方式
+const (
+	PayAA      = 1 //AA支付
+	PayWinner  = 2 //赢家支付
+	PayCreator = 3 //房主支付
+)
+
 type GameType int
 type SendCardType int
 type GameFrameType int
@@ -69,6 +79,7 @@ const (
 	GetRoomOnlineUserInfoPush                   = 419
 	UserChangeSeatNotify                        = 320 //换座通知
 	UserChangeSeatPush                          = 420
+	UserBalanceNotEnoughPush                    = 421 //金币或者积分不足 无法开始游戏
 )
 
 func UpdateUserInfoPush(roomId string) any {
@@ -79,6 +90,36 @@ func UpdateUserInfoPush(roomId string) any {
 	return pushMsg
 }
 
+// UpdateUserGoldPush 金币变化 {"gold": 9958, "pushRouter": 'UpdateUserInfoPush'}
+func UpdateUserGoldPush(gold int64) any {
+	pushMsg := map[string]any{
+		"gold":       gold,
+		"pushRouter": "UpdateUserInfoPush",
+	}
+	return pushMsg
+}
+
+// UpdateUserScorePush 联盟积分变化
+func UpdateUserScorePush(score int64) any {
+	pushMsg := map[string]any{
+		"score":      score,
+		"pushRouter": "UpdateUserInfoPush",
+	}
+	return pushMsg
+}
+
+// UserBalanceNotEnoughPushData 金币或者积分不够最少下注 取消准备
+func UserBalanceNotEnoughPushData(code int) any {
+	pushMsg := map[string]any{
+		"type": UserBalanceNotEnoughPush,
+		"data": map[string]any{
+			"code": code,
+		},
+		"pushRouter": "RoomMessagePush",
+	}
+	return pushMsg
+}
+
 func UserLeaveRoomPushData(roomUserInfo *RoomUser) any {
 	pushMsg := map[string]any{
 		"type": UserLeaveRoomPush,
@@ -89,6 +130,16 @@ func UserLeaveRoomPushData(roomUserInfo *RoomUser) any {
 	}
 	return pushMsg
 }
+func UserLeaveRoomResponseData(code int) any {
+	pushMsg := map[string]any{
+		"type": UserLeaveRoomResponse,
+		"data": map[string]any{
+			"code": code,
+		},
+		"pushRouter": "RoomMessagePush",
+	}
+	return pushMsg
+}
 func UserReadyPushData(chairID int) any {
 	pushMsg := map[string]any{
 		"type": UserReadyPush,
@@ -111,6 +162,78 @@ func OtherUserEntryRoomPushData(roomUserInfo *RoomUser) any {
 	return pushMsg
 }
 
+func UserOffLinePushData(chairID int) any {
+	pushMsg := map[string]any{
+		"type": UserOffLinePush,
+		"data": map[string]any{
+			"chairID": chairID,
+		},
+		"pushRouter": "RoomMessagePush",
+	}
+	return pushMsg
+}
+
+func UserReconnectPushData(roomUserInfo *RoomUser) any {
+	pushMsg := map[string]any{
+		"type": UserReconnectPush,
+		"data": map[string]any{
+			"roomUserInfo": roomUserInfo,
+		},
+		"pushRouter": "RoomMessagePush",
+	}
+	return pushMsg
+}
+
+// ChatType 聊天类型
+type ChatType int
+
+const (
+	ChatText     ChatType = 1 //文字
+	ChatPhrase   ChatType = 2 //快捷语 msg为快捷语id
+	ChatEmoji    ChatType = 3 //表情 msg为表情id
+	ChatInteract ChatType = 4 //互动表情 msg为表情id，发给recipientID座位上的玩家
+	ChatVoice    ChatType = 5 //语音 msg为gate上传语音返回的id，房间规则yuyin开启时允许
+)
+
+type ChatMessage struct {
+	ChairID     int      `json:"chairID"`
+	Type        ChatType `json:"type"`
+	Msg         string   `json:"msg"`
+	RecipientID int      `json:"recipientID"`
+	Time        int64    `json:"time"`
+}
+
+func UserChatPushData(msg *ChatMessage) any {
+	pushMsg := map[string]any{
+		"type":       UserChatPush,
+		"data":       msg,
+		"pushRouter": "RoomMessagePush",
+	}
+	return pushMsg
+}
+
+// ChangeSeatStatus 换座推送的状态
+type ChangeSeatStatus int
+
+const (
+	ChangeSeatAsk     ChangeSeatStatus = 1 //请求和座位上的玩家交换 只推送给对方
+	ChangeSeatDone    ChangeSeatStatus = 2 //换座成功 推送给房间内所有人
+	ChangeSeatRefused ChangeSeatStatus = 3 //对方拒绝交换 只推送给发起人
+)
+
+func UserChangeSeatPushData(fromChairID int, toChairID int, status ChangeSeatStatus) any {
+	pushMsg := map[string]any{
+		"type": UserChangeSeatPush,
+		"data": map[string]any{
+			"fromChairID": fromChairID,
+			"toChairID":   toChairID,
+			"status":      status,
+		},
+		"pushRouter": "RoomMessagePush",
+	}
+	return pushMsg
+}
+
 type DismissPushData struct {
 	NameArr    []string `json:"nameArr"`
 	ChairIDArr []any    `json:"chairIDArr"` //如果对方是第一次弹出解散框 any==nil
@@ -119,8 +242,17 @@ type DismissPushData struct {
 	AskChairId int      `json:"askChairId"`
 	Tm         int      `json:"tm"`
 	ScoreArr   []int    `json:"scoreArr"`
+	Status     int      `json:"status"` //DismissVoting DismissPassed DismissRejected DismissFailed
 }
 
+// 解散投票的状态
+const (
+	DismissVoting   = 0
+	DismissPassed   = 1 //所有人同意 房间解散
+	DismissRejected = 2 //有人拒绝或者超时有在线的玩家没有投票
+	DismissFailed   = 3 //所有人同意 但是退回已经结算的输赢失败，房间不解散
+)
+
 func AskForDismissPushData(data *DismissPushData) any {
 	pushMsg := map[string]any{
 		"type":       AskForDismissPush,
@@ -129,3 +261,49 @@ func AskForDismissPushData(data *DismissPushData) any {
 	}
 	return pushMsg
 }
+
+// AskForDismissStatusPushData 查询解散投票的状态 没有进行中的投票时data为nil
+func AskForDismissStatusPushData(data *DismissPushData) any {
+	pushMsg := map[string]any{
+		"type":       AskForDismissStatusPush,
+		"data":       data,
+		"pushRouter": "RoomMessagePush",
+	}
+	return pushMsg
+}
+
+// DrawFinishedPushData 规则中的局数已经打完
+func DrawFinishedPushData(bureau int) any {
+	pushMsg := map[string]any{
+		"type": DrawFinishedPush,
+		"data": map[string]any{
+			"bureau": bureau,
+		},
+		"pushRouter": "RoomMessagePush",
+	}
+	return pushMsg
+}
+
+// EndPushData 最终结果 每个玩家每一局的得分、总分以及大赢家
+func EndPushData(record *entity.GameRecord) any {
+	pushMsg := map[string]any{
+		"type":       EndPush,
+		"data":       record,
+		"pushRouter": "RoomMessagePush",
+	}
+	return pushMsg
+}
+
+// RoomDismissPushData 房间解散 游戏进行中解散时settle表示是否结算已经完成的局数
+// code不为0时是解散的原因 例如这一局结算失败
+func RoomDismissPushData(settle bool, code int) any {
+	pushMsg := map[string]any{
+		"type": DismissPush,
+		"data": map[string]any{
+			"settle": settle,
+			"code":   code,
+		},
+		"pushRouter": "RoomMessagePush",
+	}
+	return pushMsg
+}
@@ -35,6 +35,7 @@ type UserInfo struct {
 	RoomID       string `json:"roomID"`
 }
 
+// UserStatus 按位组合 例如准备之后掉线为Ready|Offline
 type UserStatus int
 
 const (
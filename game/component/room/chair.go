@@ -0,0 +1,49 @@
+package room
+
+// defaultChairCount 规则中没有配置最大人数时 最多6人参加 0-5有6个号
+const defaultChairCount = 6
+
+// chairs 座位分配 座位号 -> uid，空字符串表示空座位
+type chairs []string
+
+func newChairs(count int) chairs {
+	if count <= 0 {
+		count = defaultChairCount
+	}
+	return make(chairs, count)
+}
+
+// take 分配编号最小的空座位 没有空座位返回-1
+func (c chairs) take(uid string) int {
+	for i, v := range c {
+		if len(v) == 0 {
+			c[i] = uid
+			return i
+		}
+	}
+	return -1
+}
+
+func (c chairs) valid(chairID int) bool {
+	return chairID >= 0 && chairID < len(c)
+}
+
+// uid 座位上的用户 空座位返回空字符串
+func (c chairs) uid(chairID int) string {
+	if !c.valid(chairID) {
+		return ""
+	}
+	return c[chairID]
+}
+
+func (c chairs) set(chairID int, uid string) {
+	if c.valid(chairID) {
+		c[chairID] = uid
+	}
+}
+
+func (c chairs) free(chairID int, uid string) {
+	if c.valid(chairID) && c[chairID] == uid {
+		c[chairID] = ""
+	}
+}
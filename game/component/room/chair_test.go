@@ -0,0 +1,24 @@
+package room
+
+import "testing"
+
+func TestChairsTake(t *testing.T) {
+	c := newChairs(3)
+	if c.take("a") != 0 || c.take("b") != 1 {
+		t.Fatal("should take chairs in order")
+	}
+	c.free(0, "a")
+	if got := c.take("c"); got != 0 {
+		t.Fatalf("should reuse freed chair 0, got %d", got)
+	}
+	if got := c.take("d"); got != 2 {
+		t.Fatalf("should take chair 2, got %d", got)
+	}
+	if got := c.take("e"); got != -1 {
+		t.Fatalf("full room should return -1, got %d", got)
+	}
+	c.free(1, "a")
+	if c.uid(1) != "b" {
+		t.Fatal("free should not release other user's chair")
+	}
+}
@@ -0,0 +1,161 @@
+package room
+
+import (
+	"common/biz"
+	"common/logs"
+	"framework/game"
+	"framework/remote"
+	"game/component/proto"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	chatHistorySize = 20 //场景推送中带上最近的聊天记录
+	chatMaxLength   = 60 //文字消息最多字数
+	chatIdMaxLength = 16 //快捷语和表情id的长度
+	chatRateCount   = 5  //chatRateWindow内每人最多发送的条数
+	chatRateWindow  = 10 * time.Second
+)
+
+// chat 房间聊天 在房间协程中读写
+type chat struct {
+	history []*proto.ChatMessage
+	sent    map[string][]time.Time //uid -> chatRateWindow内的发送时间
+}
+
+func newChat() *chat {
+	return &chat{
+		sent: make(map[string][]time.Time),
+	}
+}
+
+// allow 限制每个人的发送频率
+func (c *chat) allow(uid string, now time.Time) bool {
+	times := c.sent[uid]
+	valid := times[:0]
+	for _, t := range times {
+		if now.Sub(t) < chatRateWindow {
+			valid = append(valid, t)
+		}
+	}
+	if len(valid) >= chatRateCount {
+		c.sent[uid] = valid
+		return false
+	}
+	c.sent[uid] = append(valid, now)
+	return true
+}
+
+func (c *chat) add(msg *proto.ChatMessage) {
+	c.history = append(c.history, msg)
+	if len(c.history) > chatHistorySize {
+		c.history = c.history[len(c.history)-chatHistorySize:]
+	}
+}
+
+func (c *chat) remove(uid string) {
+	delete(c.sent, uid)
+}
+
+// UserChat 聊天 房间内的UserChatNotify和各个游戏的聊天通知都在这里处理
+func (r *Room) UserChat(session *remote.Session, chatType proto.ChatType, msg string, recipientID int) {
+	uid := session.GetUid()
+	if _, ok := r.users[uid]; !ok {
+		return
+	}
+	log := session.Log().With(logs.KeyRoomId, r.Id)
+	switch chatType {
+	case proto.ChatText:
+		msg = strings.TrimSpace(msg)
+		if len(msg) == 0 || utf8.RuneCountInString(msg) > chatMaxLength {
+			log.Warn("chat text invalid,length=%d", utf8.RuneCountInString(msg))
+			return
+		}
+		msg = filterBannedWords(msg, game.Conf.Settings().ChatBannedWords)
+	case proto.ChatPhrase, proto.ChatEmoji:
+		if len(msg) == 0 || len(msg) > chatIdMaxLength {
+			log.Warn("chat id invalid:%s", msg)
+			return
+		}
+	case proto.ChatInteract:
+		if len(msg) == 0 || len(msg) > chatIdMaxLength || len(r.chairs.uid(recipientID)) == 0 {
+			log.Warn("chat interact invalid:%s,recipientID=%d", msg, recipientID)
+			return
+		}
+	case proto.ChatVoice:
+		if !r.gameRule.Yuyin {
+			log.Warn("chat voice rejected: %v", biz.VoiceDisabled)
+			return
+		}
+		if !primitive.IsValidObjectID(msg) {
+			log.Warn("chat voice id invalid:%s", msg)
+			return
+		}
+		//查询语音归属需要访问数据库 放到房间协程之外，查完再回到房间协程发送
+		r.checkVoiceOwner(uid, msg, func() {
+			r.sendChat(session, chatType, msg, recipientID)
+		})
+		return
+	default:
+		log.Warn("chat type invalid:%d", chatType)
+		return
+	}
+	r.sendChat(session, chatType, msg, recipientID)
+}
+
+// sendChat 校验通过的消息限频之后推送给房间内所有人
+func (r *Room) sendChat(session *remote.Session, chatType proto.ChatType, msg string, recipientID int) {
+	uid := session.GetUid()
+	user, ok := r.users[uid]
+	if !ok {
+		return
+	}
+	log := session.Log().With(logs.KeyRoomId, r.Id)
+	now := time.Now()
+	if !r.chat.allow(uid, now) {
+		log.Warn("chat too frequently")
+		return
+	}
+	message := &proto.ChatMessage{
+		ChairID:     user.ChairID,
+		Type:        chatType,
+		Msg:         msg,
+		RecipientID: recipientID,
+		Time:        now.UnixMilli(),
+	}
+	r.chat.add(message)
+	r.ServerMessagePush(r.allReceivers(), proto.UserChatPushData(message), session)
+}
+
+// checkVoiceOwner 语音是当前用户在这个房间上传的才执行send
+// 在单独的协程中查询，send通过Post回到房间协程执行
+func (r *Room) checkVoiceOwner(uid string, id string, send func()) {
+	if r.store == nil {
+		send()
+		return
+	}
+	go func() {
+		meta := r.store.FindVoice(id)
+		if meta == nil || meta.Uid != uid || meta.RoomID != r.Id {
+			logs.Warn("chat voice not uploaded by user in this room:%s,uid=%s,roomId=%s", id, uid, r.Id)
+			return
+		}
+		r.Post(send)
+	}()
+}
+
+// filterBannedWords 屏蔽词替换为*
+func filterBannedWords(msg string, words []string) string {
+	for _, w := range words {
+		if len(w) == 0 {
+			continue
+		}
+		if strings.Contains(msg, w) {
+			msg = strings.ReplaceAll(msg, w, strings.Repeat("*", utf8.RuneCountInString(w)))
+		}
+	}
+	return msg
+}
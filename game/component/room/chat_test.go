@@ -0,0 +1,58 @@
+package room
+
+import (
+	"game/component/proto"
+	"testing"
+	"time"
+)
+
+func TestChatAllow(t *testing.T) {
+	c := newChat()
+	now := time.Now()
+	for i := 0; i < chatRateCount; i++ {
+		if !c.allow("u1", now) {
+			t.Fatalf("message %d should be allowed", i)
+		}
+	}
+	if c.allow("u1", now) {
+		t.Fatal("message over the rate limit should be rejected")
+	}
+	if !c.allow("u2", now) {
+		t.Fatal("rate limit should be per user")
+	}
+	if !c.allow("u1", now.Add(chatRateWindow)) {
+		t.Fatal("message after the window should be allowed")
+	}
+}
+
+func TestFilterBannedWords(t *testing.T) {
+	cases := []struct {
+		msg   string
+		words []string
+		want  string
+	}{
+		{"hello", nil, "hello"},
+		{"hello", []string{""}, "hello"},
+		{"bad word", []string{"bad"}, "*** word"},
+		{"你好笨蛋啊", []string{"笨蛋"}, "你好**啊"},
+		{"笨蛋bad笨蛋", []string{"笨蛋", "bad"}, "*******"},
+	}
+	for _, c := range cases {
+		if got := filterBannedWords(c.msg, c.words); got != c.want {
+			t.Fatalf("filterBannedWords(%q,%q)=%q, want %q", c.msg, c.words, got, c.want)
+		}
+	}
+}
+
+func TestChatHistorySize(t *testing.T) {
+	c := newChat()
+	for i := 0; i < chatHistorySize+5; i++ {
+		c.add(&proto.ChatMessage{ChairID: i})
+	}
+	if len(c.history) != chatHistorySize {
+		t.Fatalf("history size %d, want %d", len(c.history), chatHistorySize)
+	}
+	if c.history[0].ChairID != 5 || c.history[chatHistorySize-1].ChairID != chatHistorySize+4 {
+		t.Fatalf("history should keep the latest messages, got %d..%d", c.history[0].ChairID, c.history[chatHistorySize-1].ChairID)
+	}
+}
@@ -0,0 +1,153 @@
+package room
+
+import (
+	"common/biz"
+	"framework/remote"
+	"game/component/base"
+	"game/component/proto"
+	"time"
+)
+
+// dismissVoteTime 解散投票的时间 超时后离线没有投票的玩家视为同意
+const dismissVoteTime = 30 * time.Second
+
+// DismissVote 解散投票 在房间协程中读写
+type DismissVote struct {
+	AskChairID int          `json:"askChairId"`
+	Votes      map[int]bool `json:"votes"`    //chairID -> 是否同意
+	Deadline   int64        `json:"deadline"` //毫秒时间戳
+	timer      *base.Timer
+}
+
+// askForDismiss 申请解散或者对解散投票 没有进行中的投票时同意即发起投票
+// 有一个人拒绝投票就取消，所有人都同意才解散
+func (r *Room) askForDismiss(session *remote.Session, agree bool) {
+	user, ok := r.users[session.GetUid()]
+	if !ok {
+		return
+	}
+	if r.dismissVote == nil {
+		if !agree {
+			return
+		}
+		r.dismissVote = &DismissVote{
+			AskChairID: user.ChairID,
+			Votes:      make(map[int]bool),
+			Deadline:   time.Now().Add(dismissVoteTime).UnixMilli(),
+		}
+		r.armDismissVote(session, dismissVoteTime)
+	}
+	r.dismissVote.Votes[user.ChairID] = agree
+	if !agree {
+		r.finishDismissVote(proto.DismissRejected, session)
+		return
+	}
+	for _, v := range r.users {
+		if !r.dismissVote.Votes[v.ChairID] {
+			r.sendData(proto.AskForDismissPushData(r.dismissPushData(proto.DismissVoting)), session)
+			return
+		}
+	}
+	r.finishDismissVote(proto.DismissPassed, session)
+}
+
+// armDismissVote 启动投票的定时器 恢复房间时用剩余的时间重新启动
+func (r *Room) armDismissVote(session *remote.Session, d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	r.dismissVote.timer = r.AfterFunc(d, func() {
+		r.dismissVoteTimeout(session)
+	})
+}
+
+// dismissVoteTimeout 离线没有投票的玩家自动同意 仍有在线的玩家没有投票则取消
+func (r *Room) dismissVoteTimeout(session *remote.Session) {
+	vote := r.dismissVote
+	if vote == nil {
+		return
+	}
+	status := proto.DismissPassed
+	for _, v := range r.users {
+		if _, ok := vote.Votes[v.ChairID]; ok {
+			continue
+		}
+		if v.UserStatus&proto.Offline != 0 {
+			vote.Votes[v.ChairID] = true
+		} else {
+			status = proto.DismissRejected
+		}
+	}
+	r.finishDismissVote(status, session)
+}
+
+func (r *Room) finishDismissVote(status int, session *remote.Session) {
+	if r.dismissVote.timer != nil {
+		r.dismissVote.timer.Stop()
+	}
+	//已经打过的局数或者正在进行的这一局 不管现在是否在一局中
+	played := r.gameStarted || r.record != nil
+	if status == proto.DismissPassed && !r.gameRule.DismissSettle {
+		//每局结束时已经结算 全部作废需要退回，退回失败不解散，避免余额和总结算不一致
+		if err := r.voidSettle(session); err != nil {
+			session.Log().Error("void settle failed,keep room,roomId=%s,err=%v", r.Id, err)
+			status = proto.DismissFailed
+		} else {
+			r.record = nil
+		}
+	}
+	data := r.dismissPushData(status)
+	r.dismissVote = nil
+	r.sendData(proto.AskForDismissPushData(data), session)
+	if status != proto.DismissPassed {
+		return
+	}
+	session.Log().Info("room dismissed by vote,roomId=%s,gameStarted=%v", r.Id, r.gameStarted)
+	if played {
+		r.sendData(proto.RoomDismissPushData(r.gameRule.DismissSettle, biz.OK), session)
+	}
+	r.finishRoom(session)
+}
+
+// dismissStatusPush 查询解散投票的状态 断线重连之后使用
+func (r *Room) dismissStatusPush(session *remote.Session) {
+	uid := session.GetUid()
+	if _, ok := r.users[uid]; !ok {
+		return
+	}
+	var data *proto.DismissPushData
+	if r.dismissVote != nil {
+		data = r.dismissPushData(proto.DismissVoting)
+	}
+	r.ServerMessagePush([]string{uid}, proto.AskForDismissStatusPushData(data), session)
+}
+
+func (r *Room) dismissPushData(status int) *proto.DismissPushData {
+	vote := r.dismissVote
+	count := len(r.chairs)
+	nameArr := make([]string, count)
+	chairIDArr := make([]any, count)
+	avatarArr := make([]string, count)
+	onlineArr := make([]bool, count)
+	for _, v := range r.users {
+		nameArr[v.ChairID] = v.UserInfo.Nickname
+		avatarArr[v.ChairID] = v.UserInfo.Avatar
+		onlineArr[v.ChairID] = v.UserStatus&proto.Offline == 0
+		if agree, ok := vote.Votes[v.ChairID]; ok {
+			chairIDArr[v.ChairID] = agree
+		}
+	}
+	tm := int(time.Until(time.UnixMilli(vote.Deadline)).Seconds())
+	if tm < 0 || status != proto.DismissVoting {
+		tm = 0
+	}
+	return &proto.DismissPushData{
+		NameArr:    nameArr,
+		ChairIDArr: chairIDArr,
+		AvatarArr:  avatarArr,
+		OnlineArr:  onlineArr,
+		AskChairId: vote.AskChairID,
+		Tm:         tm,
+		Status:     status,
+	}
+}
@@ -0,0 +1,122 @@
+package room
+
+import (
+	"common/biz"
+	"game/component/proto"
+	"testing"
+)
+
+func dismissStatus(t *testing.T, tr *testRoom) int {
+	t.Helper()
+	pushes := tr.pushes(proto.AskForDismissPush)
+	if len(pushes) == 0 {
+		t.Fatal("no dismiss vote push")
+	}
+	return int(pushes[len(pushes)-1]["status"].(float64))
+}
+
+func TestDismissVoteAccept(t *testing.T) {
+	tr := newTestRoom(t, proto.GameRule{DismissSettle: true}, "u1", "u2")
+	tr.askForDismiss(tr.session("u1"), true)
+	if tr.dismissVote == nil || tr.roomDismissed {
+		t.Fatal("vote should wait for u2")
+	}
+	tr.askForDismiss(tr.session("u2"), true)
+	if !tr.roomDismissed || tr.dismissVote != nil {
+		t.Fatal("room should be dismissed after everyone agreed")
+	}
+	if got := dismissStatus(t, tr); got != proto.DismissPassed {
+		t.Fatalf("status %d, want %d", got, proto.DismissPassed)
+	}
+}
+
+func TestDismissVoteReject(t *testing.T) {
+	tr := newTestRoom(t, proto.GameRule{}, "u1", "u2")
+	tr.askForDismiss(tr.session("u1"), true)
+	tr.askForDismiss(tr.session("u2"), false)
+	if tr.roomDismissed || tr.dismissVote != nil {
+		t.Fatal("rejected vote should keep the room and clear the vote")
+	}
+	if got := dismissStatus(t, tr); got != proto.DismissRejected {
+		t.Fatalf("status %d, want %d", got, proto.DismissRejected)
+	}
+}
+
+func TestDismissVoteTimeout(t *testing.T) {
+	cases := []struct {
+		name      string
+		offline   bool
+		dismissed bool
+	}{
+		{"offline auto accept", true, true},
+		{"online not voted", false, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tr := newTestRoom(t, proto.GameRule{}, "u1", "u2")
+			if c.offline {
+				tr.users["u2"].UserStatus |= proto.Offline
+			}
+			tr.askForDismiss(tr.session("u1"), true)
+			tr.dismissVoteTimeout(tr.session("u1"))
+			if tr.roomDismissed != c.dismissed {
+				t.Fatalf("dismissed=%v, want %v", tr.roomDismissed, c.dismissed)
+			}
+		})
+	}
+}
+
+func TestDismissVoteSettle(t *testing.T) {
+	cases := []struct {
+		name          string
+		dismissSettle bool
+		inHand        bool
+		voids         int
+	}{
+		{"settle between hands", true, false, 0},
+		{"settle in hand", true, true, 0},
+		{"void between hands", false, false, 1},
+		{"void in hand", false, true, 1},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tr := newTestRoom(t, proto.GameRule{DismissSettle: c.dismissSettle}, "u1", "u2")
+			tr.playHands([]int{3, -3})
+			tr.gameStarted = c.inHand
+			tr.askForDismiss(tr.session("u1"), true)
+			tr.askForDismiss(tr.session("u2"), true)
+			if !tr.roomDismissed {
+				t.Fatal("room should be dismissed")
+			}
+			if len(tr.store.voids) != c.voids {
+				t.Fatalf("voids %d, want %d", len(tr.store.voids), c.voids)
+			}
+			if c.voids > 0 && (tr.store.gold["u1"] != 0 || tr.store.gold["u2"] != 0) {
+				t.Fatalf("balances should be restored, got %v", tr.store.gold)
+			}
+			if got := len(tr.store.records); (got == 1) != c.dismissSettle {
+				t.Fatalf("saved records %d, dismissSettle=%v", got, c.dismissSettle)
+			}
+			if pushes := tr.pushes(proto.DismissPush); len(pushes) != 1 || pushes[0]["settle"] != c.dismissSettle {
+				t.Fatalf("room dismiss push %v", pushes)
+			}
+		})
+	}
+}
+
+func TestDismissVoteVoidFailed(t *testing.T) {
+	tr := newTestRoom(t, proto.GameRule{}, "u1", "u2")
+	tr.playHands([]int{3, -3})
+	tr.store.voidErr = biz.SqlError
+	tr.askForDismiss(tr.session("u1"), true)
+	tr.askForDismiss(tr.session("u2"), true)
+	if tr.roomDismissed || tr.record == nil {
+		t.Fatal("room and record should be kept when void fails")
+	}
+	if got := dismissStatus(t, tr); got != proto.DismissFailed {
+		t.Fatalf("status %d, want %d", got, proto.DismissFailed)
+	}
+	if pushes := tr.pushes(proto.DismissPush); len(pushes) != 0 {
+		t.Fatalf("room dismiss should not be pushed, got %v", pushes)
+	}
+}
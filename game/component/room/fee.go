@@ -0,0 +1,178 @@
+package room
+
+import (
+	"common/biz"
+	"common/logs"
+	"core/models/entity"
+	"framework/msError"
+	"framework/remote"
+	"game/component/proto"
+)
+
+// 房费 规则中的payDiamond是整个房间的费用
+// AA支付: 每个玩家第一次参加游戏时扣除自己的那一份
+// 赢家支付: 每个玩家第一次参加游戏时先扣除全部房费作为押金，房间结束时大赢家平分，
+// 没有大赢家时所有交过押金的玩家平分，多扣的部分退还
+// 房主支付: 创建房间时扣除
+// 第一局开始之前解散 已经扣除的全部退还
+
+// shareFee 平分房费 不能整除时向上取整
+func shareFee(total int, count int) int64 {
+	if total <= 0 {
+		return 0
+	}
+	if count <= 0 {
+		count = 1
+	}
+	return int64((total + count - 1) / count)
+}
+
+// entryFee 进入房间时金币至少要有的数量
+func (r *Room) entryFee(creator bool) int64 {
+	switch r.gameRule.PayType {
+	case proto.PayAA:
+		return shareFee(r.gameRule.PayDiamond, len(r.chairs))
+	case proto.PayWinner:
+		return int64(r.gameRule.PayDiamond)
+	case proto.PayCreator:
+		if creator {
+			return int64(r.gameRule.PayDiamond)
+		}
+	}
+	return 0
+}
+
+// checkEntryFee 创建或者加入房间时校验金币 房主支付时创建房间就扣除
+func (r *Room) checkEntryFee(session *remote.Session, data *entity.User) *msError.Error {
+	creator := r.RoomCreator == nil
+	fee := r.entryFee(creator)
+	if fee <= 0 {
+		return nil
+	}
+	if data.Gold < fee {
+		return biz.NotEnoughGold
+	}
+	if creator && r.gameRule.PayType == proto.PayCreator {
+		gold, err := r.chargeFee(session, data.Uid, fee)
+		if err != nil {
+			return err
+		}
+		if r.store != nil {
+			data.Gold = gold
+		}
+	}
+	return nil
+}
+
+// chargeEntryFees AA支付和赢家支付 开始游戏时扣除还没有支付过的玩家的房费或者押金，金币不足的玩家踢出房间
+func (r *Room) chargeEntryFees(session *remote.Session) {
+	if r.gameRule.PayType != proto.PayAA && r.gameRule.PayType != proto.PayWinner {
+		return
+	}
+	fee := r.entryFee(false)
+	if fee <= 0 {
+		return
+	}
+	for uid, user := range r.users {
+		if _, ok := r.feePaid[uid]; ok {
+			continue
+		}
+		_, err := r.chargeFee(session, uid, fee)
+		if err == nil {
+			continue
+		}
+		if err.Code != biz.NotEnoughGold.Code {
+			//其他错误不是玩家的原因 保留在房间中，下一局开始时重试
+			session.Log().Error("charge room fee failed,uid=%s,roomId=%s,err=%v", uid, r.Id, err)
+			continue
+		}
+		session.Log().Warn("room fee not enough,uid=%s,roomId=%s", uid, r.Id)
+		r.kickUser(user, session)
+	}
+}
+
+// settleFees 房间解散时处理已经扣除的房费 没有开始过游戏全部退还，赢家支付退还押金中多扣的部分
+func (r *Room) settleFees(session *remote.Session) {
+	if r.handsStarted == 0 {
+		r.refundFees(session, nil)
+		return
+	}
+	if r.gameRule.PayType != proto.PayWinner {
+		return
+	}
+	payers := r.bigWinners()
+	if len(payers) == 0 {
+		for uid := range r.feePaid {
+			payers[uid] = true
+		}
+	}
+	fee := shareFee(r.gameRule.PayDiamond, len(payers))
+	keep := make(map[string]int64, len(payers))
+	for uid := range payers {
+		keep[uid] = fee
+	}
+	r.refundFees(session, keep)
+}
+
+// bigWinners 总分最高并且赢分的玩家
+func (r *Room) bigWinners() map[string]bool {
+	winners := make(map[string]bool)
+	if r.record == nil {
+		return winners
+	}
+	maxTotal := 0
+	for _, p := range r.record.Players {
+		if p.Total > maxTotal {
+			maxTotal = p.Total
+		}
+	}
+	for _, p := range r.record.Players {
+		if maxTotal > 0 && p.Total == maxTotal {
+			winners[p.Uid] = true
+		}
+	}
+	return winners
+}
+
+// chargeFee 扣除房费 返回扣除之后的金币
+func (r *Room) chargeFee(session *remote.Session, uid string, fee int64) (int64, *msError.Error) {
+	if r.store == nil {
+		return 0, nil
+	}
+	gold, err := r.store.ChargeRoomFee(uid, r.Id, r.instanceId, fee)
+	if err != nil {
+		return 0, err
+	}
+	r.feePaid[uid] += fee
+	r.updateGold(session, uid, gold)
+	return gold, nil
+}
+
+// refundFees 退还已经扣除的房费 keep为每个玩家保留不退的部分
+func (r *Room) refundFees(session *remote.Session, keep map[string]int64) {
+	if r.store == nil {
+		return
+	}
+	for uid, paid := range r.feePaid {
+		fee := paid - keep[uid]
+		if fee <= 0 {
+			continue
+		}
+		gold, err := r.store.RefundRoomFee(uid, r.Id, r.instanceId, fee)
+		if err != nil {
+			logs.Error("refund room fee failed,uid=%s,roomId=%s,fee=%d,err=%v", uid, r.Id, fee, err)
+			continue
+		}
+		r.feePaid[uid] = paid - fee
+		if session != nil {
+			r.updateGold(session, uid, gold)
+		}
+	}
+}
+
+func (r *Room) updateGold(session *remote.Session, uid string, gold int64) {
+	if user, ok := r.users[uid]; ok {
+		user.UserInfo.Gold = gold
+	}
+	r.ServerMessagePush([]string{uid}, proto.UpdateUserGoldPush(gold), session)
+}
@@ -0,0 +1,103 @@
+package room
+
+import (
+	"common/biz"
+	"game/component/proto"
+	"testing"
+)
+
+func TestShareFee(t *testing.T) {
+	cases := []struct {
+		total, count int
+		want         int64
+	}{
+		{0, 4, 0},
+		{8, 4, 2},
+		{9, 4, 3},
+		{5, 0, 5},
+	}
+	for _, c := range cases {
+		if got := shareFee(c.total, c.count); got != c.want {
+			t.Fatalf("shareFee(%d,%d)=%d, want %d", c.total, c.count, got, c.want)
+		}
+	}
+}
+
+func TestChargeEntryFees(t *testing.T) {
+	tr := newTestRoom(t, proto.GameRule{PayType: proto.PayAA, PayDiamond: 6}, "u1", "u2", "u3")
+	tr.store.gold["u1"] = 10
+	tr.store.chargeErr["u3"] = biz.SqlError
+	tr.chargeEntryFees(tr.session("u1"))
+	if tr.feePaid["u1"] != 2 || tr.store.gold["u1"] != 8 {
+		t.Fatalf("u1 should pay 2, feePaid=%v gold=%v", tr.feePaid, tr.store.gold)
+	}
+	if _, ok := tr.users["u2"]; ok {
+		t.Fatal("u2 without enough gold should be kicked")
+	}
+	if _, ok := tr.users["u3"]; !ok {
+		t.Fatal("u3 should stay when the charge fails for other reasons")
+	}
+	if _, ok := tr.feePaid["u3"]; ok {
+		t.Fatal("u3 should be charged again at the next hand")
+	}
+}
+
+func TestRefundFeesBeforeFirstHand(t *testing.T) {
+	cases := []struct {
+		name         string
+		handsStarted int
+		refunds      int
+	}{
+		{"no hand started", 0, 2},
+		{"first hand settle failed", 1, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tr := newTestRoom(t, proto.GameRule{PayType: proto.PayAA, PayDiamond: 4}, "u1", "u2")
+			tr.store.gold["u1"] = 10
+			tr.store.gold["u2"] = 10
+			tr.chargeEntryFees(tr.session("u1"))
+			tr.handsStarted = c.handsStarted
+			tr.finishRoom(tr.session("u1"))
+			if len(tr.store.refunds) != c.refunds {
+				t.Fatalf("refunds %v, want %d", tr.store.refunds, c.refunds)
+			}
+		})
+	}
+}
+
+func TestPayWinnerFee(t *testing.T) {
+	cases := []struct {
+		name   string
+		scores []int
+		kept   map[string]int64
+	}{
+		{"one winner", []int{5, -2, -3}, map[string]int64{"u1": 9}},
+		{"two winners", []int{4, 4, -8}, map[string]int64{"u1": 5, "u2": 5}},
+		{"no winner", []int{0, 0, 0}, map[string]int64{"u1": 3, "u2": 3, "u3": 3}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tr := newTestRoom(t, proto.GameRule{PayType: proto.PayWinner, PayDiamond: 9}, "u1", "u2", "u3")
+			for _, uid := range []string{"u1", "u2", "u3"} {
+				tr.store.gold[uid] = 100
+			}
+			tr.chargeEntryFees(tr.session("u1"))
+			for _, uid := range []string{"u1", "u2", "u3"} {
+				if tr.feePaid[uid] != 9 {
+					t.Fatalf("deposit of %s is %d, want 9", uid, tr.feePaid[uid])
+				}
+			}
+			tr.playHands(c.scores)
+			tr.finishRoom(tr.session("u1"))
+			for i, uid := range []string{"u1", "u2", "u3"} {
+				if tr.feePaid[uid] != c.kept[uid] {
+					t.Fatalf("%s paid %d, want %d", uid, tr.feePaid[uid], c.kept[uid])
+				}
+				if want := 100 + int64(c.scores[i]) - c.kept[uid]; tr.store.gold[uid] != want {
+					t.Fatalf("%s gold %d, want %d", uid, tr.store.gold[uid], want)
+				}
+			}
+		})
+	}
+}
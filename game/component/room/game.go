@@ -9,4 +9,8 @@ type GameFrame interface {
 	GetGameData(session *remote.Session) any
 	StartGame(session *remote.Session, user *proto.RoomUser)
 	GameMessageHandle(user *proto.RoomUser, session *remote.Session, msg []byte)
+	// Snapshot 游戏数据快照 包括牌堆以及进行中的定时操作
+	Snapshot() ([]byte, error)
+	// Restore 从快照恢复游戏数据并重新启动定时任务 在房间协程中调用
+	Restore(data []byte, session *remote.Session) error
 }
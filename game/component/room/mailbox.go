@@ -0,0 +1,104 @@
+package room
+
+import (
+	"common/logs"
+	"runtime/debug"
+	"sync"
+)
+
+const mailboxSize = 1024
+
+// Scheduler 所有房间共享，限制同时执行任务的房间数量(servers.json maxRunRoutineNum)
+type Scheduler struct {
+	sem chan struct{}
+}
+
+func NewScheduler(maxRunRoutineNum int) *Scheduler {
+	if maxRunRoutineNum <= 0 {
+		maxRunRoutineNum = 1
+	}
+	return &Scheduler{
+		sem: make(chan struct{}, maxRunRoutineNum),
+	}
+}
+
+func (s *Scheduler) acquire() {
+	s.sem <- struct{}{}
+}
+
+func (s *Scheduler) release() {
+	<-s.sem
+}
+
+// mailbox 房间的消息队列
+// 客户端消息、定时任务、房间生命周期事件都投递到这里，由房间自己的协程串行执行
+type mailbox struct {
+	tasks     chan func()
+	scheduler *Scheduler
+	closeChan chan struct{}
+	closeOnce sync.Once
+}
+
+func newMailbox(scheduler *Scheduler) *mailbox {
+	m := &mailbox{
+		tasks:     make(chan func(), mailboxSize),
+		scheduler: scheduler,
+		closeChan: make(chan struct{}),
+	}
+	go m.run()
+	return m
+}
+
+func (m *mailbox) run() {
+	for {
+		select {
+		case <-m.closeChan:
+			return
+		case task := <-m.tasks:
+			if m.closed() {
+				return
+			}
+			m.scheduler.acquire()
+			m.execute(task)
+			m.scheduler.release()
+		}
+	}
+}
+
+func (m *mailbox) execute(task func()) {
+	defer func() {
+		if err := recover(); err != nil {
+			logs.Error("room task panic:%v\n%s", err, debug.Stack())
+		}
+	}()
+	task()
+}
+
+// post 投递任务，房间关闭之后的任务直接丢弃
+func (m *mailbox) post(task func()) bool {
+	if m.closed() {
+		return false
+	}
+	select {
+	case m.tasks <- task:
+		return true
+	case <-m.closeChan:
+		return false
+	}
+}
+
+func (m *mailbox) closed() bool {
+	select {
+	case <-m.closeChan:
+		return true
+	default:
+		return false
+	}
+}
+
+// close 关闭之后，正在执行的任务完成后协程退出，队列中剩余的任务丢弃
+func (m *mailbox) close() {
+	m.closeOnce.Do(func() {
+		close(m.closeChan)
+	})
+}
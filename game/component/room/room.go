@@ -1,7 +1,9 @@
 package room
 
 import (
+	"common/biz"
 	"common/logs"
+	"common/metrics"
 	"core/models/entity"
 	"framework/msError"
 	"framework/remote"
@@ -10,65 +12,185 @@ import (
 	"game/component/proto"
 	"game/component/sz"
 	"game/models/request"
-	"sync"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strconv"
+	"sync/atomic"
 	"time"
 )
 
+// maxWatcherCount 每个房间观战人数上限
+const maxWatcherCount = 20
+
+// Room 房间 所有状态都只在房间自己的协程(mailbox)中读写
 type Room struct {
-	sync.RWMutex
 	Id            string
+	instanceId    string //创建房间时生成 房间号会被重复使用，余额变化的幂等key使用instanceId
 	unionID       int64
 	gameRule      proto.GameRule
 	users         map[string]*proto.RoomUser
 	RoomCreator   *proto.RoomCreator
 	GameFrame     GameFrame
-	kickSchedules map[string]*time.Timer
+	kickSchedules map[string]*base.Timer
 	union         base.UnionBase
 	roomDismissed bool
 	gameStarted   bool
-	askDismiss    map[int]struct{}
+	handUsers     map[string]int //这一局开始时参与的玩家 uid -> chairID 局结束之后清空
+	handsStarted  int            //已经开始的局数 包括结算失败和作废的局，用来判断解散时是否退还房费
+	dismissVote   *DismissVote
+	record        *entity.GameRecord //已经打完的局的得分 局数打完或者解散时推送总结算
+	feePaid       map[string]int64   //已经扣除的房费 uid -> 金币
+	mailbox       *mailbox
+	store         Store
+	connectorId   string                     //最近一次请求来自的connector
+	userCids      map[string]string          //用户当前的连接 忽略旧连接的断开通知
+	watchers      map[string]*proto.RoomUser //观战的用户 不占座位，不保存到快照
+	chairs        chairs
+	seatRequests  map[string]int //换座请求 发起人uid -> 目标座位
+	chat          *chat
+	lastSnapshot  []byte
+	persistDue    bool //已经安排了延迟保存
+}
+
+// Post 将任务投递到房间协程中执行，执行完之后延迟保存快照
+func (r *Room) Post(task func()) bool {
+	return r.mailbox.post(func() {
+		task()
+		r.persistLater()
+	})
+}
+
+// Call 投递任务并等待执行结果
+func (r *Room) Call(task func() *msError.Error) *msError.Error {
+	result := make(chan *msError.Error, 1)
+	var started atomic.Bool
+	if !r.Post(func() {
+		started.Store(true)
+		result <- task()
+	}) {
+		return biz.RoomNotExist
+	}
+	select {
+	case err := <-result:
+		return err
+	case <-r.mailbox.closeChan:
+		//房间已关闭 任务没有执行的话 不会再执行了
+		if started.Load() {
+			return <-result
+		}
+		return biz.RoomNotExist
+	}
+}
+
+// AfterFunc 定时任务 回调同样在房间协程中执行
+func (r *Room) AfterFunc(d time.Duration, f func()) *base.Timer {
+	return base.NewTimer(d, func(task func()) {
+		r.Post(task)
+	}, f)
 }
 
 func (r *Room) UserReady(uid string, session *remote.Session) {
 	r.userReady(uid, session)
 }
 
-func (r *Room) EndGame(session *remote.Session) {
+// EndGame 一局结束 scores为按座次的得分
+func (r *Room) EndGame(session *remote.Session, scores []int) {
+	if r.gameStarted {
+		metrics.GamesFinished.WithLabelValues(r.gameTypeLabel()).Inc()
+		if err := r.settle(session, scores); err != nil {
+			//余额没有变化 这一局不计入记录，解散房间避免继续在错误的余额上游戏
+			session.Log().Error("settle failed,dismiss room,roomId=%s,scores=%v,err=%v", r.Id, scores, err)
+			r.gameStarted = false
+			r.handUsers = nil
+			//之前的局已经结算 这一局作废，通知玩家房间因为结算失败解散
+			r.sendData(proto.RoomDismissPushData(true, biz.SettleFailed.Code), session)
+			r.Post(func() {
+				r.finishRoom(session)
+			})
+			return
+		}
+		r.recordHand(scores)
+	}
 	r.gameStarted = false
+	r.handUsers = nil
 	for k := range r.users {
-		r.users[k].UserStatus = proto.None
+		//掉线的状态保留到重连
+		r.users[k].UserStatus &= proto.Offline
+	}
+	if r.bureauFinished() {
+		//等游戏把这一局的结算推送完 再推送总结算
+		r.Post(func() {
+			r.finishRoom(session)
+		})
+		return
+	}
+	if r.union.Draining() {
+		//等游戏把结算等消息推送完 再解散
+		r.Post(func() {
+			r.closeForDrain(session)
+		})
 	}
 }
 
 func (r *Room) UserEntryRoom(session *remote.Session, data *entity.User) *msError.Error {
+	return r.Call(func() *msError.Error {
+		return r.userEntryRoom(session, data)
+	})
+}
+
+func (r *Room) userEntryRoom(session *remote.Session, data *entity.User) *msError.Error {
+	if r.roomDismissed {
+		return biz.RoomNotExist
+	}
 	curUid := session.GetUid()
+	r.connectorId = session.ConnectorId()
 	_, ok1 := r.kickSchedules[curUid]
 	if ok1 {
 		r.kickSchedules[curUid].Stop()
 		delete(r.kickSchedules, curUid)
 	}
-	r.RoomCreator = &proto.RoomCreator{
-		Uid: data.Uid,
+	if _, ok := r.users[data.Uid]; !ok {
+		chairID := r.chairs.take(data.Uid)
+		if chairID < 0 {
+			return biz.RoomPlayerCountFull
+		}
+		if err := r.checkEntryFee(session, data); err != nil {
+			r.chairs.free(chairID, data.Uid)
+			return err
+		}
+		r.users[data.Uid] = proto.ToRoomUser(data, chairID)
+		if r.unionID != PersonalUnionId {
+			r.users[data.Uid].UserInfo.Score = r.unionScore(data)
+		}
 	}
-	if r.unionID == 1 {
-		r.RoomCreator.CreatorType = proto.UserCreatorType
-	} else {
-		r.RoomCreator.CreatorType = proto.UnionCreatorType
+	if r.RoomCreator == nil {
+		//第一个进入房间的用户是房主
+		r.RoomCreator = &proto.RoomCreator{
+			Uid: data.Uid,
+		}
+		if r.unionID == PersonalUnionId {
+			r.RoomCreator.CreatorType = proto.UserCreatorType
+		} else {
+			r.RoomCreator.CreatorType = proto.UnionCreatorType
+		}
 	}
-	//最多6人参加 0-5有6个号
-	chairID := r.getEmptyChairID()
-	_, ok := r.users[data.Uid]
-	if !ok {
-		r.users[data.Uid] = proto.ToRoomUser(data, chairID)
+	delete(r.watchers, data.Uid)
+	r.users[data.Uid].UserStatus &^= proto.Offline
+	r.users[data.Uid].UserInfo.FrontendId = r.connectorId
+	r.userCids[data.Uid] = session.Cid()
+	if r.store != nil {
+		r.store.UpdateUserRoom(data.Uid, r.Id, r.connectorId)
 	}
 	//2. 将房间号 推送给客户端 更新数据库 当前房间号存储起来
 	r.UpdateUserInfoRoomPush(session, data.Uid)
 	session.Put("roomId", r.Id)
+	session.Put("gameType", r.gameRule.GameType)
+	//之后的game请求都路由到房间所在的节点
+	session.BindServer("game")
 	//3. 将游戏类型 推送给客户端 （用户进入游戏的推送）
 	r.SelfEntryRoomPush(session, data.Uid)
 	//4.告诉其他人 此用户进入房间了
 	r.OtherUserEntryRoomPush(session, data.Uid)
-	go r.addKickScheduleEvent(session, data.Uid)
+	r.addKickScheduleEvent(session, data.Uid)
 	return nil
 }
 
@@ -93,6 +215,13 @@ func (r *Room) SelfEntryRoomPush(session *remote.Session, uid string) {
 }
 
 func (r *Room) RoomMessageHandle(session *remote.Session, req request.RoomMessageReq) {
+	r.Post(func() {
+		r.roomMessageHandle(session, req)
+	})
+}
+
+func (r *Room) roomMessageHandle(session *remote.Session, req request.RoomMessageReq) {
+	r.connectorId = session.ConnectorId()
 	if req.Type == proto.UserReadyNotify {
 		r.userReady(session.GetUid(), session)
 	}
@@ -102,6 +231,105 @@ func (r *Room) RoomMessageHandle(session *remote.Session, req request.RoomMessag
 	if req.Type == proto.AskForDismissNotify {
 		r.askForDismiss(session, req.Data.IsExit)
 	}
+	if req.Type == proto.AskForDismissStatusNotify {
+		r.dismissStatusPush(session)
+	}
+	if req.Type == proto.UserReconnectNotify {
+		r.userReconnect(session)
+	}
+	if req.Type == proto.UserLeaveRoomNotify {
+		r.userLeaveRoom(session)
+	}
+	if req.Type == proto.UserChangeSeatNotify {
+		r.userChangeSeat(session, req.Data.ChairID, req.Data.IsAgree)
+	}
+	if req.Type == proto.UserChatNotify {
+		r.UserChat(session, req.Data.Type, req.Data.Msg, req.Data.RecipientID)
+	}
+}
+
+// userLeaveRoom 主动离开房间 游戏中不能离开，最后一个人离开之后解散房间
+func (r *Room) userLeaveRoom(session *remote.Session) {
+	uid := session.GetUid()
+	user, ok := r.users[uid]
+	if !ok {
+		r.ServerMessagePush([]string{uid}, proto.UserLeaveRoomResponseData(biz.NotInRoom.Code), session)
+		return
+	}
+	if r.inHand(uid) {
+		r.ServerMessagePush([]string{uid}, proto.UserLeaveRoomResponseData(biz.CanNotLeaveRoom.Code), session)
+		return
+	}
+	if timer, ok := r.kickSchedules[uid]; ok {
+		timer.Stop()
+		delete(r.kickSchedules, uid)
+	}
+	if r.dismissVote != nil {
+		delete(r.dismissVote.Votes, user.ChairID)
+	}
+	r.kickUser(user, session)
+	session.Put("roomId", "")
+	session.Put("gameType", 0)
+	session.UnbindServer("game")
+	r.ServerMessagePush([]string{uid}, proto.UserLeaveRoomResponseData(biz.OK), session)
+	if len(r.users) == 0 {
+		r.dismissRoom(session)
+	}
+}
+
+// UserOffline 客户端连接断开
+func (r *Room) UserOffline(session *remote.Session) {
+	r.Post(func() {
+		r.userOffline(session)
+	})
+}
+
+func (r *Room) userOffline(session *remote.Session) {
+	uid := session.GetUid()
+	if _, ok := r.watchers[uid]; ok {
+		//观战的用户断开 直接离开
+		delete(r.watchers, uid)
+		return
+	}
+	user, ok := r.users[uid]
+	if !ok {
+		return
+	}
+	//用户已经通过新的连接重连了
+	if cid, ok := r.userCids[uid]; ok && cid != session.Cid() {
+		return
+	}
+	if user.UserStatus&proto.Offline != 0 {
+		return
+	}
+	user.UserStatus |= proto.Offline
+	delete(r.userCids, uid)
+	r.ServerMessagePush(r.otherUsers(uid), proto.UserOffLinePushData(user.ChairID), session)
+}
+
+// userReconnect 断线重连 恢复session绑定，标记为在线并推送完整的房间场景(包含自己的手牌)
+func (r *Room) userReconnect(session *remote.Session) {
+	uid := session.GetUid()
+	user, ok := r.users[uid]
+	if !ok {
+		//已经被踢出房间 清除客户端的房间号
+		session.Put("roomId", "")
+		session.Put("gameType", 0)
+		session.UnbindServer("game")
+		r.ServerMessagePush([]string{uid}, proto.UpdateUserInfoPush(""), session)
+		return
+	}
+	user.UserStatus &^= proto.Offline
+	user.UserInfo.FrontendId = r.connectorId
+	r.userCids[uid] = session.Cid()
+	if r.store != nil {
+		r.store.UpdateUserRoom(uid, r.Id, r.connectorId)
+	}
+	session.Put("roomId", r.Id)
+	session.Put("gameType", r.gameRule.GameType)
+	session.BindServer("game")
+	r.ServerMessagePush(r.otherUsers(uid), proto.UserReconnectPushData(user), session)
+	r.getRoomSceneInfoPush(session)
 }
 
 func (r *Room) getRoomSceneInfoPush(session *remote.Session) {
@@ -119,21 +347,21 @@ func (r *Room) getRoomSceneInfoPush(session *remote.Session) {
 			"gameRule":        r.gameRule,
 			"roomUserInfoArr": userInfoArr,
 			"gameData":        r.GameFrame.GetGameData(session),
+			"watcherCount":    len(r.watchers),
+			"chatHistory":     r.chat.history,
 		},
 	}
 	session.Push([]string{session.GetUid()}, data, "ServerMessagePush")
 }
 
 func (r *Room) addKickScheduleEvent(session *remote.Session, uid string) {
-	r.Lock()
-	defer r.Unlock()
 	t, ok := r.kickSchedules[uid]
 	if ok {
 		t.Stop()
 		delete(r.kickSchedules, uid)
 	}
-	r.kickSchedules[uid] = time.AfterFunc(30*time.Second, func() {
-		logs.Info("kick 定时执行，代表 用户长时间未准备,uid=%v", uid)
+	r.kickSchedules[uid] = r.AfterFunc(30*time.Second, func() {
+		logs.With(logs.KeyRoomId, r.Id, logs.KeyUid, uid).Info("kick 定时执行，代表 用户长时间未准备")
 		//取消定时任务
 		timer, ok1 := r.kickSchedules[uid]
 		if ok1 {
@@ -143,19 +371,42 @@ func (r *Room) addKickScheduleEvent(session *remote.Session, uid string) {
 		//需要判断用户是否该踢出
 		user, ok2 := r.users[uid]
 		if ok2 {
-			if user.UserStatus < proto.Ready {
+			if user.UserStatus&proto.Ready == 0 {
 				r.kickUser(user, session)
 				//踢出房间之后，需要判断是否可以解散房间
 				if len(r.users) == 0 {
-					r.dismissRoom()
+					r.dismissRoom(session)
 				}
 			}
 		}
 	})
 }
 
+// ServerMessagePush 按用户所在的connector分组推送
+// 定时任务以及恢复之后的房间使用的session不一定是用户自己的connector
 func (r *Room) ServerMessagePush(users []string, data any, session *remote.Session) {
-	session.Push(users, data, "ServerMessagePush")
+	groups := make(map[string][]string)
+	for _, uid := range users {
+		dst := r.frontendId(uid)
+		if len(dst) == 0 {
+			dst = session.ConnectorId()
+		}
+		groups[dst] = append(groups[dst], uid)
+	}
+	for dst, uids := range groups {
+		session.PushTo(dst, uids, data, "ServerMessagePush")
+	}
+}
+
+// frontendId 用户所在的connector 不在房间中返回空
+func (r *Room) frontendId(uid string) string {
+	if user, ok := r.users[uid]; ok {
+		return user.UserInfo.FrontendId
+	}
+	if user, ok := r.watchers[uid]; ok {
+		return user.UserInfo.FrontendId
+	}
+	return ""
 }
 func (r *Room) kickUser(user *proto.RoomUser, session *remote.Session) {
 	//将roomId设为空
@@ -165,22 +416,32 @@ func (r *Room) kickUser(user *proto.RoomUser, session *remote.Session) {
 	for _, v := range r.users {
 		users = append(users, v.UserInfo.Uid)
 	}
-	r.ServerMessagePush(users, proto.UserLeaveRoomPushData(user), session)
+	r.ServerMessagePush(append(users, r.GetWatchers()...), proto.UserLeaveRoomPushData(user), session)
 	delete(r.users, user.UserInfo.Uid)
+	delete(r.userCids, user.UserInfo.Uid)
+	r.chairs.free(user.ChairID, user.UserInfo.Uid)
+	r.clearSeatRequests(user.UserInfo.Uid, user.ChairID)
+	r.chat.remove(user.UserInfo.Uid)
+	if r.store != nil {
+		r.store.UpdateUserRoom(user.UserInfo.Uid, "", user.UserInfo.FrontendId)
+	}
 }
 
-func (r *Room) dismissRoom() {
-	if r.TryLock() {
-		r.Lock()
-		defer r.Unlock()
-	}
+func (r *Room) dismissRoom(session *remote.Session) {
 	if r.roomDismissed {
 		return
 	}
+	if session != nil {
+		r.kickWatchers(session)
+	}
+	r.settleFees(session)
 	r.roomDismissed = true
+	metrics.Rooms.WithLabelValues(r.gameTypeLabel()).Dec()
 	//解散 将union当中存储的room信息 删除掉
 	r.cancelAllScheduler()
 	r.union.DismissRoom(r.Id)
+	//房间协程退出 之后投递的消息全部丢弃
+	r.mailbox.close()
 }
 
 func (r *Room) cancelAllScheduler() {
@@ -194,7 +455,8 @@ func (r *Room) cancelAllScheduler() {
 func (r *Room) userReady(uid string, session *remote.Session) {
 	//1. push用户的座次,修改用户的状态，取消定时任务
 	user, ok := r.users[uid]
-	if !ok {
+	if !ok || r.gameStarted {
+		//游戏中准备会清除Playing状态
 		return
 	}
 	user.UserStatus = proto.Ready
@@ -204,8 +466,7 @@ func (r *Room) userReady(uid string, session *remote.Session) {
 		delete(r.kickSchedules, uid)
 
 	}
-	allUsers := r.AllUsers()
-	r.ServerMessagePush(allUsers, proto.UserReadyPushData(user.ChairID), session)
+	r.ServerMessagePush(r.allReceivers(), proto.UserReadyPushData(user.ChairID), session)
 	//2. 准备好之后，判断是否需要开始游戏
 	if r.IsStartGame() {
 		r.startGame(session, user)
@@ -218,16 +479,31 @@ func (r *Room) JoinRoom(session *remote.Session, data *entity.User) *msError.Err
 }
 
 func (r *Room) OtherUserEntryRoomPush(session *remote.Session, uid string) {
+	user, ok := r.users[uid]
+	if ok {
+		r.ServerMessagePush(r.otherUsers(uid), proto.OtherUserEntryRoomPushData(user), session)
+	}
+}
+
+// otherUsers 除uid之外的玩家和观战的用户
+func (r *Room) otherUsers(uid string) []string {
 	others := make([]string, 0)
 	for _, v := range r.users {
 		if v.UserInfo.Uid != uid {
 			others = append(others, v.UserInfo.Uid)
 		}
 	}
-	user, ok := r.users[uid]
-	if ok {
-		r.ServerMessagePush(others, proto.OtherUserEntryRoomPushData(user), session)
+	for k := range r.watchers {
+		if k != uid {
+			others = append(others, k)
+		}
 	}
+	return others
+}
+
+// allReceivers 房间内公开消息的接收者 玩家和观战的用户
+func (r *Room) allReceivers() []string {
+	return append(r.AllUsers(), r.GetWatchers()...)
 }
 
 func (r *Room) AllUsers() []string {
@@ -238,27 +514,11 @@ func (r *Room) AllUsers() []string {
 	return users
 }
 
-func (r *Room) getEmptyChairID() int {
-	if len(r.users) == 0 {
-		return 0
-	}
-	r.Lock()
-	defer r.Unlock()
-	chairID := 0
-	for _, v := range r.users {
-		if v.ChairID == chairID {
-			//座位号被占用了
-			chairID++
-		}
-	}
-	return chairID
-}
-
 func (r *Room) IsStartGame() bool {
 	//房间内准备的人数 已经大于等于 最小开始游戏人数
 	userReadyCount := 0
 	for _, v := range r.users {
-		if v.UserStatus == proto.Ready {
+		if v.UserStatus&proto.Ready != 0 {
 			userReadyCount++
 		}
 	}
@@ -277,21 +537,60 @@ func (r *Room) startGame(session *remote.Session, user *proto.RoomUser) {
 	if r.gameStarted {
 		return
 	}
+	if r.union.Draining() {
+		r.closeForDrain(session)
+		return
+	}
+	r.chargeEntryFees(session)
+	if len(r.users) == 0 {
+		r.dismissRoom(session)
+		return
+	}
+	if !r.IsStartGame() {
+		//有玩家房费不足被踢出
+		return
+	}
+	if !r.checkStake(session) {
+		return
+	}
 	r.gameStarted = true
+	r.handsStarted++
+	r.seatRequests = make(map[string]int)
+	metrics.GamesStarted.WithLabelValues(r.gameTypeLabel()).Inc()
+	r.handUsers = make(map[string]int, len(r.users))
 	for _, v := range r.users {
-		v.UserStatus = proto.Playing
+		v.UserStatus = proto.Playing | v.UserStatus&proto.Offline
+		r.handUsers[v.UserInfo.Uid] = v.ChairID
 	}
 	r.GameFrame.StartGame(session, user)
 }
 
-func NewRoom(id string, unionID int64, rule proto.GameRule, u base.UnionBase) *Room {
+// inHand 用户参与了正在进行的这一局
+func (r *Room) inHand(uid string) bool {
+	if !r.gameStarted {
+		return false
+	}
+	_, ok := r.handUsers[uid]
+	return ok
+}
+
+func NewRoom(id string, unionID int64, rule proto.GameRule, u base.UnionBase, scheduler *Scheduler, store Store) *Room {
 	r := &Room{
 		Id:            id,
+		instanceId:    primitive.NewObjectID().Hex(),
 		unionID:       unionID,
 		gameRule:      rule,
 		users:         make(map[string]*proto.RoomUser),
-		kickSchedules: make(map[string]*time.Timer),
+		kickSchedules: make(map[string]*base.Timer),
+		userCids:      make(map[string]string),
+		watchers:      make(map[string]*proto.RoomUser),
+		chairs:        newChairs(rule.MaxPlayerCount),
+		seatRequests:  make(map[string]int),
+		feePaid:       make(map[string]int64),
+		chat:          newChat(),
 		union:         u,
+		mailbox:       newMailbox(scheduler),
+		store:         store,
 	}
 	if rule.GameType == int(proto.PinSanZhang) {
 		r.GameFrame = sz.NewGameFrame(rule, r)
@@ -299,9 +598,14 @@ func NewRoom(id string, unionID int64, rule proto.GameRule, u base.UnionBase) *R
 	if rule.GameType == int(proto.HongZhong) {
 		r.GameFrame = mj.NewGameFrame(rule, r)
 	}
+	metrics.Rooms.WithLabelValues(r.gameTypeLabel()).Inc()
 	return r
 }
 
+func (r *Room) gameTypeLabel() string {
+	return strconv.Itoa(r.gameRule.GameType)
+}
+
 func (r *Room) GetUsers() map[string]*proto.RoomUser {
 	return r.users
 }
@@ -309,6 +613,13 @@ func (r *Room) GetId() string {
 	return r.Id
 }
 func (r *Room) GameMessageHandle(session *remote.Session, msg []byte) {
+	r.Post(func() {
+		r.gameMessageHandle(session, msg)
+	})
+}
+
+func (r *Room) gameMessageHandle(session *remote.Session, msg []byte) {
+	r.connectorId = session.ConnectorId()
 	//需要游戏去处理具体的消息
 	user, ok := r.users[session.GetUid()]
 	if !ok {
@@ -317,77 +628,6 @@ func (r *Room) GameMessageHandle(session *remote.Session, msg []byte) {
 	r.GameFrame.GameMessageHandle(user, session, msg)
 }
 
-func (r *Room) askForDismiss(session *remote.Session, exist bool) {
-	r.Lock()
-	defer r.Unlock()
-	//所有同意座次的数组
-	if exist {
-		//同意解散
-		if r.askDismiss == nil {
-			r.askDismiss = make(map[int]struct{})
-		}
-		user := r.users[session.GetUid()]
-		r.askDismiss[user.ChairID] = struct{}{}
-		nameArr := make([]string, len(r.users))
-		chairIDArr := make([]any, len(r.users))
-		avatarArr := make([]string, len(r.users))
-		onlineArr := make([]bool, len(r.users))
-		for _, v := range r.users {
-			nameArr[v.ChairID] = v.UserInfo.Nickname
-			avatarArr[v.ChairID] = v.UserInfo.Avatar
-			_, ok := r.askDismiss[v.ChairID]
-			if ok {
-				chairIDArr[v.ChairID] = true
-			}
-			onlineArr[v.ChairID] = true
-		}
-		data := proto.DismissPushData{
-			NameArr:    nameArr,
-			ChairIDArr: chairIDArr,
-			AskChairId: user.ChairID,
-			OnlineArr:  onlineArr,
-			AvatarArr:  avatarArr,
-			Tm:         30,
-		}
-		r.sendData(proto.AskForDismissPushData(&data), session)
-		if len(r.askDismiss) == len(r.users) {
-			//所有人都同意 解散
-			for _, v := range r.users {
-				r.kickUser(v, session)
-			}
-			if len(r.users) == 0 {
-				r.dismissRoom()
-			}
-		}
-
-	} else {
-		user := r.users[session.GetUid()]
-		//不同意解散
-		nameArr := make([]string, len(r.users))
-		chairIDArr := make([]any, len(r.users))
-		avatarArr := make([]string, len(r.users))
-		onlineArr := make([]bool, len(r.users))
-		for _, v := range r.users {
-			nameArr[v.ChairID] = v.UserInfo.Nickname
-			avatarArr[v.ChairID] = v.UserInfo.Avatar
-			_, ok := r.askDismiss[v.ChairID]
-			if ok {
-				chairIDArr[v.ChairID] = true
-			}
-			onlineArr[v.ChairID] = true
-		}
-		data := proto.DismissPushData{
-			NameArr:    nameArr,
-			ChairIDArr: chairIDArr,
-			AskChairId: user.ChairID,
-			OnlineArr:  onlineArr,
-			AvatarArr:  avatarArr,
-			Tm:         30,
-		}
-		r.sendData(proto.AskForDismissPushData(&data), session)
-	}
-}
-
 func (r *Room) sendData(data any, session *remote.Session) {
-	r.ServerMessagePush(r.AllUsers(), data, session)
+	r.ServerMessagePush(r.allReceivers(), data, session)
 }
@@ -0,0 +1,224 @@
+package room
+
+import (
+	"common/biz"
+	"core/models/entity"
+	"encoding/json"
+	"framework/msError"
+	"framework/remote"
+	"game/component/proto"
+	"sync"
+	"testing"
+)
+
+// fakeStore 记录房费和结算的调用 err不为空时对应的操作失败
+type fakeStore struct {
+	mu        sync.Mutex
+	gold      map[string]int64
+	chargeErr map[string]*msError.Error
+	settleErr *msError.Error
+	voidErr   *msError.Error
+	charges   []string
+	refunds   []string
+	settles   []map[string]int64
+	voids     []map[string]int64
+	records   []*entity.GameRecord
+	userRooms map[string]string
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		gold:      make(map[string]int64),
+		chargeErr: make(map[string]*msError.Error),
+		userRooms: make(map[string]string),
+	}
+}
+
+func (s *fakeStore) SaveRoom(roomId string, data []byte) {}
+func (s *fakeStore) DeleteRoom(roomId string)            {}
+func (s *fakeStore) UpdateUserRoom(uid string, roomId string, frontendId string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.userRooms[uid] = roomId
+}
+func (s *fakeStore) SaveGameRecord(record *entity.GameRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, record)
+}
+func (s *fakeStore) ChargeRoomFee(uid string, roomId string, instanceId string, fee int64) (int64, *msError.Error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.chargeErr[uid]; err != nil {
+		return 0, err
+	}
+	if s.gold[uid] < fee {
+		return 0, biz.NotEnoughGold
+	}
+	s.gold[uid] -= fee
+	s.charges = append(s.charges, uid)
+	return s.gold[uid], nil
+}
+func (s *fakeStore) RefundRoomFee(uid string, roomId string, instanceId string, fee int64) (int64, *msError.Error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gold[uid] += fee
+	s.refunds = append(s.refunds, uid)
+	return s.gold[uid], nil
+}
+func (s *fakeStore) FindVoice(id string) *entity.VoiceMeta {
+	return nil
+}
+func (s *fakeStore) Settle(roomId string, instanceId string, hand int, unionId int64, deltas map[string]int64) (map[string]int64, *msError.Error) {
+	if s.settleErr != nil {
+		return nil, s.settleErr
+	}
+	s.settles = append(s.settles, deltas)
+	return s.apply(deltas), nil
+}
+func (s *fakeStore) VoidSettle(roomId string, instanceId string, unionId int64, deltas map[string]int64) (map[string]int64, *msError.Error) {
+	if s.voidErr != nil {
+		return nil, s.voidErr
+	}
+	s.voids = append(s.voids, deltas)
+	return s.apply(deltas), nil
+}
+func (s *fakeStore) apply(deltas map[string]int64) map[string]int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	balances := make(map[string]int64, len(deltas))
+	for uid, delta := range deltas {
+		s.gold[uid] += delta
+		balances[uid] = s.gold[uid]
+	}
+	return balances
+}
+
+type fakeUnion struct {
+	dismissed []string
+}
+
+func (u *fakeUnion) DismissRoom(roomId string) { u.dismissed = append(u.dismissed, roomId) }
+func (u *fakeUnion) Draining() bool            { return false }
+
+// pushClient 解码发出的推送 记录每条推送的数据
+type pushClient struct {
+	mu     sync.Mutex
+	pushes []map[string]any
+}
+
+func (c *pushClient) Run() error   { return nil }
+func (c *pushClient) Close() error { return nil }
+func (c *pushClient) SendMsg(dst string, data []byte) error {
+	msg, err := remote.MsgDecode(data)
+	if err != nil || msg.Body == nil {
+		return err
+	}
+	var push map[string]any
+	if err := json.Unmarshal(msg.Body.Data, &push); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pushes = append(c.pushes, push)
+	return nil
+}
+
+// testRoom 在测试协程中直接调用房间的方法 flush之后检查推送
+type testRoom struct {
+	*Room
+	store      *fakeStore
+	union      *fakeUnion
+	client     *pushClient
+	dispatcher *remote.PushDispatcher
+}
+
+func newTestRoom(t *testing.T, rule proto.GameRule, uids ...string) *testRoom {
+	t.Helper()
+	if rule.MaxPlayerCount == 0 {
+		rule.MaxPlayerCount = len(uids)
+	}
+	store := newFakeStore()
+	union := &fakeUnion{}
+	client := &pushClient{}
+	tr := &testRoom{
+		Room:       NewRoom("100001", PersonalUnionId, rule, union, NewScheduler(1), store),
+		store:      store,
+		union:      union,
+		client:     client,
+		dispatcher: remote.NewPushDispatcher(client, 0),
+	}
+	for _, uid := range uids {
+		chairID := tr.chairs.take(uid)
+		tr.users[uid] = &proto.RoomUser{
+			UserInfo: proto.UserInfo{Uid: uid, FrontendId: "connector001"},
+			ChairID:  chairID,
+		}
+	}
+	t.Cleanup(tr.dispatcher.Close)
+	return tr
+}
+
+func (tr *testRoom) session(uid string) *remote.Session {
+	return remote.NewSession(tr.dispatcher, &remote.Msg{Uid: uid, Src: "connector001", Dst: "game001"})
+}
+
+// pushes 等待已经发出的推送全部送达 返回指定类型的推送数据
+func (tr *testRoom) pushes(pushType int) []map[string]any {
+	tr.dispatcher.Close()
+	tr.client.mu.Lock()
+	defer tr.client.mu.Unlock()
+	result := make([]map[string]any, 0)
+	for _, push := range tr.client.pushes {
+		if v, ok := push["type"].(float64); ok && int(v) == pushType {
+			data, _ := push["data"].(map[string]any)
+			result = append(result, data)
+		}
+	}
+	return result
+}
+
+// playHands 模拟已经打完并且结算的局数 scores为每一局按座次的得分
+func (tr *testRoom) playHands(hands ...[]int) {
+	for _, scores := range hands {
+		tr.gameStarted = true
+		tr.handsStarted++
+		tr.handUsers = make(map[string]int, len(tr.users))
+		for uid, v := range tr.users {
+			tr.handUsers[uid] = v.ChairID
+		}
+		deltas := make(map[string]int64)
+		for uid, chairID := range tr.handUsers {
+			deltas[uid] = int64(scores[chairID])
+		}
+		tr.store.apply(deltas)
+		tr.recordHand(scores)
+		tr.gameStarted = false
+		tr.handUsers = nil
+	}
+}
+
+func TestEndGameSettleFailed(t *testing.T) {
+	tr := newTestRoom(t, proto.GameRule{BaseScore: 1}, "u1", "u2")
+	tr.playHands([]int{2, -2})
+	tr.store.settleErr = biz.SqlError
+	tr.gameStarted = true
+	tr.handsStarted++
+	tr.handUsers = map[string]int{"u1": 0, "u2": 1}
+	tr.EndGame(tr.session("u1"), []int{5, -5})
+	//等待投递的finishRoom执行完
+	tr.Call(func() *msError.Error { return nil })
+	if !tr.roomDismissed {
+		t.Fatal("room should be dismissed after settle failed")
+	}
+	if tr.record.Bureau != 1 {
+		t.Fatalf("failed hand should not be recorded, bureau=%d", tr.record.Bureau)
+	}
+	pushes := tr.pushes(proto.DismissPush)
+	if len(pushes) != 1 || int(pushes[0]["code"].(float64)) != biz.SettleFailed.Code {
+		t.Fatalf("settle failed dismiss push %v", pushes)
+	}
+	if len(tr.pushes(proto.EndPush)) != 1 {
+		t.Fatal("settled hands should still be pushed")
+	}
+}
@@ -0,0 +1,66 @@
+package room
+
+import (
+	"framework/remote"
+	"game/component/proto"
+)
+
+// userChangeSeat 换座 只能在两局之间进行
+// 目标是空座位直接换过去，有人的座位推送给对方，对方发送同样的通知(chairID为发起人的座位)同意或者拒绝
+func (r *Room) userChangeSeat(session *remote.Session, chairID int, agree bool) {
+	uid := session.GetUid()
+	user, ok := r.users[uid]
+	if !ok || r.gameStarted || !r.chairs.valid(chairID) || chairID == user.ChairID {
+		session.Log().Warn("change seat ignored,roomId=%s,chairID=%d,gameStarted=%v", r.Id, chairID, r.gameStarted)
+		return
+	}
+	target := r.chairs.uid(chairID)
+	if len(target) == 0 {
+		r.moveChair(user, chairID, session)
+		return
+	}
+	other := r.users[target]
+	//对方之前请求和自己交换 这次是回复
+	if to, ok := r.seatRequests[target]; ok && to == user.ChairID {
+		delete(r.seatRequests, target)
+		if !agree {
+			r.ServerMessagePush([]string{target}, proto.UserChangeSeatPushData(other.ChairID, user.ChairID, proto.ChangeSeatRefused), session)
+			return
+		}
+		r.swapChair(other, user, session)
+		return
+	}
+	r.seatRequests[uid] = chairID
+	r.ServerMessagePush([]string{target}, proto.UserChangeSeatPushData(user.ChairID, chairID, proto.ChangeSeatAsk), session)
+}
+
+func (r *Room) moveChair(user *proto.RoomUser, chairID int, session *remote.Session) {
+	from := user.ChairID
+	uid := user.UserInfo.Uid
+	r.clearSeatRequests(uid, from)
+	r.chairs.free(from, uid)
+	r.chairs.set(chairID, uid)
+	user.ChairID = chairID
+	r.ServerMessagePush(r.allReceivers(), proto.UserChangeSeatPushData(from, chairID, proto.ChangeSeatDone), session)
+}
+
+// swapChair 交换from和to两个玩家的座位
+func (r *Room) swapChair(from *proto.RoomUser, to *proto.RoomUser, session *remote.Session) {
+	fromChairID, toChairID := from.ChairID, to.ChairID
+	r.clearSeatRequests(from.UserInfo.Uid, fromChairID)
+	r.clearSeatRequests(to.UserInfo.Uid, toChairID)
+	from.ChairID, to.ChairID = toChairID, fromChairID
+	r.chairs.set(toChairID, from.UserInfo.Uid)
+	r.chairs.set(fromChairID, to.UserInfo.Uid)
+	r.ServerMessagePush(r.allReceivers(), proto.UserChangeSeatPushData(fromChairID, toChairID, proto.ChangeSeatDone), session)
+}
+
+// clearSeatRequests 用户换座或者离开之后 删除他发起的以及换到他座位的请求
+func (r *Room) clearSeatRequests(uid string, chairID int) {
+	delete(r.seatRequests, uid)
+	for k, v := range r.seatRequests {
+		if v == chairID {
+			delete(r.seatRequests, k)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package room
+
+import (
+	"common/biz"
+	"core/models/entity"
+	"framework/msError"
+	"framework/remote"
+	"game/component/proto"
+)
+
+// PersonalUnionId 普通房间的unionId 结算金币，联盟房间结算联盟积分
+const PersonalUnionId = 1
+
+// settle 将这一局的输赢写入参与这一局的玩家的金币或者联盟积分 scores为按座次的得分
+func (r *Room) settle(session *remote.Session, scores []int) *msError.Error {
+	deltas := make(map[string]int64)
+	for uid, chairID := range r.handUsers {
+		if chairID >= len(scores) || scores[chairID] == 0 {
+			continue
+		}
+		deltas[uid] = int64(scores[chairID])
+	}
+	if len(deltas) == 0 || r.store == nil {
+		return nil
+	}
+	hand := 1
+	if r.record != nil {
+		hand = r.record.Bureau + 1
+	}
+	balances, err := r.store.Settle(r.Id, r.instanceId, hand, r.unionID, deltas)
+	if err != nil {
+		return err
+	}
+	for uid, balance := range balances {
+		r.updateBalance(session, uid, balance)
+	}
+	return nil
+}
+
+// voidSettle 中途解散并且不结算 退回已经打完的局的输赢
+func (r *Room) voidSettle(session *remote.Session) *msError.Error {
+	if r.record == nil || r.store == nil {
+		return nil
+	}
+	deltas := make(map[string]int64)
+	for _, p := range r.record.Players {
+		if p.Total != 0 {
+			deltas[p.Uid] = int64(-p.Total)
+		}
+	}
+	if len(deltas) == 0 {
+		return nil
+	}
+	balances, err := r.store.VoidSettle(r.Id, r.instanceId, r.unionID, deltas)
+	if err != nil {
+		return err
+	}
+	for uid, balance := range balances {
+		r.updateBalance(session, uid, balance)
+	}
+	return nil
+}
+
+// balance 玩家当前的金币或者联盟积分
+func (r *Room) balance(user *proto.RoomUser) int64 {
+	if r.unionID == PersonalUnionId {
+		return user.UserInfo.Gold
+	}
+	return int64(user.UserInfo.Score)
+}
+
+func (r *Room) updateBalance(session *remote.Session, uid string, balance int64) {
+	if r.unionID == PersonalUnionId {
+		r.updateGold(session, uid, balance)
+		return
+	}
+	if user, ok := r.users[uid]; ok {
+		user.UserInfo.Score = int(balance)
+	}
+	r.ServerMessagePush([]string{uid}, proto.UpdateUserScorePush(balance), session)
+}
+
+// unionScore 联盟房间 用户在这个联盟中的积分
+func (r *Room) unionScore(data *entity.User) int {
+	for _, v := range data.UnionInfo {
+		if v.UnionID == r.unionID {
+			return v.Score
+		}
+	}
+	return 0
+}
+
+// minStake 开始一局需要的最少分数
+func (r *Room) minStake() int64 {
+	stake := r.gameRule.BaseScore
+	if r.gameRule.GameType == int(proto.PinSanZhang) && len(r.gameRule.AddScores) > 0 {
+		stake = r.gameRule.AddScores[0] * r.gameRule.BaseScore
+	}
+	return int64(stake)
+}
+
+// checkStake 分数不够最少下注的玩家取消准备 返回是否所有人都够
+func (r *Room) checkStake(session *remote.Session) bool {
+	stake := r.minStake()
+	if stake <= 0 {
+		return true
+	}
+	ok := true
+	for uid, v := range r.users {
+		if r.balance(v) >= stake {
+			continue
+		}
+		ok = false
+		v.UserStatus &^= proto.Ready
+		r.ServerMessagePush([]string{uid}, proto.UserBalanceNotEnoughPushData(biz.LeaveRoomGoldNotEnoughLimit.Code), session)
+		r.addKickScheduleEvent(session, uid)
+	}
+	return ok
+}
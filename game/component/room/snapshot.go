@@ -0,0 +1,240 @@
+package room
+
+import (
+	"bytes"
+	"common/logs"
+	"core/models/entity"
+	"encoding/json"
+	"fmt"
+	"framework/msError"
+	"framework/remote"
+	"game/component/base"
+	"game/component/proto"
+	"time"
+)
+
+// Store 房间持久化 由logic层实现
+type Store interface {
+	// SaveRoom 保存房间快照
+	SaveRoom(roomId string, data []byte)
+	// DeleteRoom 房间解散之后删除快照
+	DeleteRoom(roomId string)
+	// UpdateUserRoom 用户进入或者离开房间(roomId为空)
+	UpdateUserRoom(uid string, roomId string, frontendId string)
+	// SaveGameRecord 保存房间的总结算
+	SaveGameRecord(record *entity.GameRecord)
+	// ChargeRoomFee 扣除房费 返回扣除之后的金币 instanceId用于幂等
+	ChargeRoomFee(uid string, roomId string, instanceId string, fee int64) (int64, *msError.Error)
+	// RefundRoomFee 退还房费 返回退还之后的金币
+	RefundRoomFee(uid string, roomId string, instanceId string, fee int64) (int64, *msError.Error)
+	// FindVoice 查询语音的发送人和房间 不存在或者已过期返回nil
+	FindVoice(id string) *entity.VoiceMeta
+	// Settle 在一个事务中结算房间第hand局的输赢 返回结算之后的金币或者联盟积分
+	Settle(roomId string, instanceId string, hand int, unionId int64, deltas map[string]int64) (map[string]int64, *msError.Error)
+	// VoidSettle 中途解散并且不结算时 在一个事务中退回已经结算的输赢
+	VoidSettle(roomId string, instanceId string, unionId int64, deltas map[string]int64) (map[string]int64, *msError.Error)
+}
+
+// Snapshot 房间以及游戏数据的快照，每次房间状态变化之后保存，节点重启之后用来恢复房间
+type Snapshot struct {
+	Id           string                     `json:"id"`
+	InstanceId   string                     `json:"instanceId"`
+	UnionId      int64                      `json:"unionId"`
+	GameRule     proto.GameRule             `json:"gameRule"`
+	Users        map[string]*proto.RoomUser `json:"users"`
+	RoomCreator  *proto.RoomCreator         `json:"roomCreator"`
+	GameStarted  bool                       `json:"gameStarted"`
+	HandUsers    map[string]int             `json:"handUsers,omitempty"`
+	HandsStarted int                        `json:"handsStarted,omitempty"`
+	DismissVote  *DismissVote               `json:"dismissVote,omitempty"`
+	Record       *entity.GameRecord         `json:"record,omitempty"`
+	FeePaid      map[string]int64           `json:"feePaid,omitempty"`
+	ConnectorId  string                     `json:"connectorId"` //恢复之后定时任务推送消息使用
+	GameData     json.RawMessage            `json:"gameData"`
+}
+
+func (r *Room) snapshot() ([]byte, error) {
+	s := &Snapshot{
+		Id:           r.Id,
+		InstanceId:   r.instanceId,
+		UnionId:      r.unionID,
+		GameRule:     r.gameRule,
+		Users:        r.users,
+		RoomCreator:  r.RoomCreator,
+		GameStarted:  r.gameStarted,
+		HandUsers:    r.handUsers,
+		HandsStarted: r.handsStarted,
+		DismissVote:  r.dismissVote,
+		Record:       r.record,
+		FeePaid:      r.feePaid,
+		ConnectorId:  r.connectorId,
+	}
+	if r.GameFrame != nil {
+		data, err := r.GameFrame.Snapshot()
+		if err != nil {
+			return nil, err
+		}
+		s.GameData = data
+	}
+	return json.Marshal(s)
+}
+
+// persistDelay 快照延迟保存的时间 这段时间内的多次变化(例如麻将出牌倒计时)只保存一次
+// 节点崩溃最多丢失这段时间的状态，金币和积分已经在结算时写入数据库
+const persistDelay = time.Second
+
+// persistLater 房间协程中每个任务执行完之后调用 解散之后立即删除快照
+func (r *Room) persistLater() {
+	if r.store == nil {
+		return
+	}
+	if r.roomDismissed {
+		r.persist(false)
+		return
+	}
+	if r.lastSnapshot == nil {
+		//新房间立即保存 gate上传语音时根据快照校验房间规则
+		r.persist(false)
+		return
+	}
+	if r.persistDue {
+		return
+	}
+	r.persistDue = true
+	time.AfterFunc(persistDelay, func() {
+		//不经过Post 避免保存之后再次安排保存
+		r.mailbox.post(func() {
+			r.persistDue = false
+			r.persist(false)
+		})
+	})
+}
+
+// persist 数据有变化才保存
+func (r *Room) persist(force bool) {
+	if r.store == nil {
+		return
+	}
+	if r.roomDismissed {
+		if r.lastSnapshot != nil {
+			r.store.DeleteRoom(r.Id)
+			r.lastSnapshot = nil
+		}
+		return
+	}
+	data, err := r.snapshot()
+	if err != nil {
+		logs.With(logs.KeyRoomId, r.Id).Error("room snapshot err:%v", err)
+		return
+	}
+	if !force && bytes.Equal(data, r.lastSnapshot) {
+		return
+	}
+	r.store.SaveRoom(r.Id, data)
+	r.lastSnapshot = data
+}
+
+// Suspend 保存最后的快照并停止房间，之后不再处理任何消息，节点重启之后恢复
+func (r *Room) Suspend() *msError.Error {
+	return r.Call(func() *msError.Error {
+		r.cancelAllScheduler()
+		r.persist(true)
+		r.mailbox.close()
+		return nil
+	})
+}
+
+// RestoreRoom 根据快照恢复房间并重新启动定时任务
+// newSession创建向快照中connector推送消息的session
+func RestoreRoom(data []byte, u base.UnionBase, scheduler *Scheduler, store Store, newSession func(connectorId string) *remote.Session) (*Room, error) {
+	var s Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	r := NewRoom(s.Id, s.UnionId, s.GameRule, u, scheduler, store)
+	if r.GameFrame == nil {
+		r.Abandon()
+		return nil, fmt.Errorf("unknown game type %d", s.GameRule.GameType)
+	}
+	var restoreErr error
+	r.Call(func() *msError.Error {
+		r.users = s.Users
+		for uid, user := range r.users {
+			r.chairs.set(user.ChairID, uid)
+		}
+		r.RoomCreator = s.RoomCreator
+		r.instanceId = s.InstanceId
+		if len(r.instanceId) == 0 {
+			//之前版本的快照没有instanceId 沿用房间号，和已经写入的流水的幂等key一致
+			r.instanceId = s.Id
+		}
+		r.gameStarted = s.GameStarted
+		r.handUsers = s.HandUsers
+		if r.gameStarted && r.handUsers == nil {
+			//之前版本的快照没有记录 按Playing状态恢复
+			r.handUsers = make(map[string]int)
+			for uid, user := range r.users {
+				if user.UserStatus&proto.Playing != 0 {
+					r.handUsers[uid] = user.ChairID
+				}
+			}
+		}
+		r.record = s.Record
+		r.handsStarted = s.HandsStarted
+		if r.handsStarted == 0 {
+			//之前版本的快照没有记录 按打完的局数和正在进行的局恢复
+			if r.record != nil {
+				r.handsStarted = r.record.Bureau
+			}
+			if r.gameStarted {
+				r.handsStarted++
+			}
+		}
+		if s.FeePaid != nil {
+			r.feePaid = s.FeePaid
+		}
+		r.connectorId = s.ConnectorId
+		session := newSession(s.ConnectorId)
+		if s.DismissVote != nil {
+			r.dismissVote = s.DismissVote
+			r.armDismissVote(session, time.Until(time.UnixMilli(s.DismissVote.Deadline)))
+		}
+		if len(s.GameData) > 0 {
+			if restoreErr = r.GameFrame.Restore(s.GameData, session); restoreErr != nil {
+				return nil
+			}
+		}
+		if !r.gameStarted {
+			for uid, user := range r.users {
+				if user.UserStatus&proto.Ready == 0 {
+					r.addKickScheduleEvent(session, uid)
+				}
+			}
+		}
+		r.lastSnapshot = data
+		return nil
+	})
+	if restoreErr != nil {
+		r.Abandon()
+		return nil, restoreErr
+	}
+	logs.With(logs.KeyRoomId, r.Id).Info("room restored,users=%d,gameStarted=%v", len(r.users), r.gameStarted)
+	return r, nil
+}
+
+// Abandon 恢复或者创建失败 丢弃房间
+func (r *Room) Abandon() {
+	r.Call(func() *msError.Error {
+		r.dismissRoom(nil)
+		return nil
+	})
+}
+
+// closeForDrain 节点drain时 当前这局结束之后推送总结算 将所有人踢出并解散房间
+func (r *Room) closeForDrain(session *remote.Session) {
+	if r.roomDismissed {
+		return
+	}
+	logs.With(logs.KeyRoomId, r.Id).Info("server draining,dismiss room")
+	r.finishRoom(session)
+}
@@ -0,0 +1,91 @@
+package room
+
+import (
+	"core/models/entity"
+	"framework/remote"
+	"game/component/proto"
+	"time"
+)
+
+// recordHand 记录参与这一局的玩家的得分 scores按座次，在清除handUsers之前调用
+func (r *Room) recordHand(scores []int) {
+	if r.record == nil {
+		r.record = &entity.GameRecord{
+			RoomID:   r.Id,
+			UnionID:  r.unionID,
+			GameType: r.gameRule.GameType,
+		}
+	}
+	record := r.record
+	record.Bureau++
+	for uid, chairID := range r.handUsers {
+		v, ok := r.users[uid]
+		if !ok {
+			continue
+		}
+		p := recordPlayer(record, v)
+		score := 0
+		if chairID < len(scores) {
+			score = scores[chairID]
+		}
+		p.Scores = append(p.Scores, score)
+		p.Total += score
+	}
+}
+
+// recordPlayer 找到玩家的记录 中途加入的玩家之前的局数记为0
+func recordPlayer(record *entity.GameRecord, user *proto.RoomUser) *entity.GameRecordPlayer {
+	for _, p := range record.Players {
+		if p.Uid == user.UserInfo.Uid {
+			p.ChairID = user.ChairID
+			for len(p.Scores) < record.Bureau-1 {
+				p.Scores = append(p.Scores, 0)
+			}
+			return p
+		}
+	}
+	p := &entity.GameRecordPlayer{
+		Uid:      user.UserInfo.Uid,
+		Nickname: user.UserInfo.Nickname,
+		Avatar:   user.UserInfo.Avatar,
+		ChairID:  user.ChairID,
+		Scores:   make([]int, record.Bureau-1, record.Bureau),
+	}
+	record.Players = append(record.Players, p)
+	return p
+}
+
+// bureauFinished 规则中的局数已经打完 局数为0时不限制
+func (r *Room) bureauFinished() bool {
+	return r.gameRule.Bureau > 0 && r.record != nil && r.record.Bureau >= r.gameRule.Bureau
+}
+
+// finishRoom 推送总结算并保存 然后踢出所有人解散房间
+// 没有打完一局或者record为nil时只解散
+func (r *Room) finishRoom(session *remote.Session) {
+	if r.roomDismissed {
+		return
+	}
+	if record := r.record; record != nil && record.Bureau > 0 {
+		winners := r.bigWinners()
+		for _, p := range record.Players {
+			for len(p.Scores) < record.Bureau {
+				p.Scores = append(p.Scores, 0)
+			}
+			//没有人赢分时没有大赢家
+			p.BigWinner = winners[p.Uid]
+		}
+		record.CreateTime = time.Now().UnixMilli()
+		if r.bureauFinished() {
+			r.sendData(proto.DrawFinishedPushData(record.Bureau), session)
+		}
+		r.sendData(proto.EndPushData(record), session)
+		if r.store != nil {
+			r.store.SaveGameRecord(record)
+		}
+	}
+	for _, v := range r.users {
+		r.kickUser(v, session)
+	}
+	r.dismissRoom(session)
+}
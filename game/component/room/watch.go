@@ -0,0 +1,125 @@
+package room
+
+import (
+	"common/biz"
+	"core/models/entity"
+	"framework/msError"
+	"framework/remote"
+	"game/component/proto"
+)
+
+// GetWatchers 观战的用户
+func (r *Room) GetWatchers() []string {
+	watchers := make([]string, 0, len(r.watchers))
+	for k := range r.watchers {
+		watchers = append(watchers, k)
+	}
+	return watchers
+}
+
+// WatchRoom 观战 房间规则canWatch为true时允许
+func (r *Room) WatchRoom(session *remote.Session, data *entity.User) *msError.Error {
+	return r.Call(func() *msError.Error {
+		return r.watchRoom(session, data)
+	})
+}
+
+func (r *Room) watchRoom(session *remote.Session, data *entity.User) *msError.Error {
+	if r.roomDismissed {
+		return biz.RoomNotExist
+	}
+	if !r.gameRule.CanWatch {
+		return biz.CanNotWatch
+	}
+	if _, ok := r.users[data.Uid]; ok {
+		//已经是玩家了
+		return nil
+	}
+	if _, ok := r.watchers[data.Uid]; !ok && len(r.watchers) >= maxWatcherCount {
+		return biz.RoomWatcherCountFull
+	}
+	r.connectorId = session.ConnectorId()
+	r.watchers[data.Uid] = proto.ToRoomUser(data, -1)
+	r.watchers[data.Uid].UserInfo.FrontendId = r.connectorId
+	session.Put("roomId", r.Id)
+	session.Put("gameType", r.gameRule.GameType)
+	session.BindServer("game")
+	r.UpdateUserInfoRoomPush(session, data.Uid)
+	r.SelfEntryRoomPush(session, data.Uid)
+	r.getRoomSceneInfoPush(session)
+	return nil
+}
+
+// LeaveWatch 离开观战
+func (r *Room) LeaveWatch(session *remote.Session) *msError.Error {
+	return r.Call(func() *msError.Error {
+		uid := session.GetUid()
+		if _, ok := r.watchers[uid]; !ok {
+			return biz.NotInRoom
+		}
+		delete(r.watchers, uid)
+		session.Put("roomId", "")
+		session.Put("gameType", 0)
+		session.UnbindServer("game")
+		r.ServerMessagePush([]string{uid}, proto.UpdateUserInfoPush(""), session)
+		return nil
+	})
+}
+
+// SitDown 观战的用户坐下成为玩家 需要有空座位，游戏已经开始时需要房间允许中途加入
+func (r *Room) SitDown(session *remote.Session, data *entity.User) *msError.Error {
+	return r.Call(func() *msError.Error {
+		return r.sitDown(session, data)
+	})
+}
+
+func (r *Room) sitDown(session *remote.Session, data *entity.User) *msError.Error {
+	if r.roomDismissed {
+		return biz.RoomNotExist
+	}
+	uid := session.GetUid()
+	user, ok := r.watchers[uid]
+	if !ok {
+		return biz.NotInRoom
+	}
+	if r.gameStarted && !r.gameRule.CanEnter {
+		return biz.CanNotEnterGameStarted
+	}
+	chairID := r.chairs.take(uid)
+	if chairID < 0 {
+		return biz.RoomPlayerCountFull
+	}
+	if err := r.checkEntryFee(session, data); err != nil {
+		r.chairs.free(chairID, uid)
+		return err
+	}
+	user.UserInfo.Gold = data.Gold
+	if r.unionID != PersonalUnionId {
+		user.UserInfo.Score = r.unionScore(data)
+	}
+	r.connectorId = session.ConnectorId()
+	user.ChairID = chairID
+	user.UserStatus = proto.None
+	user.UserInfo.FrontendId = r.connectorId
+	delete(r.watchers, uid)
+	r.users[uid] = user
+	r.userCids[uid] = session.Cid()
+	if r.store != nil {
+		r.store.UpdateUserRoom(uid, r.Id, r.connectorId)
+	}
+	r.OtherUserEntryRoomPush(session, uid)
+	r.getRoomSceneInfoPush(session)
+	if !r.gameStarted {
+		r.addKickScheduleEvent(session, uid)
+	}
+	return nil
+}
+
+// kickWatchers 房间解散时 清除观战用户的房间号
+func (r *Room) kickWatchers(session *remote.Session) {
+	if len(r.watchers) == 0 {
+		return
+	}
+	r.ServerMessagePush(r.GetWatchers(), proto.UpdateUserInfoPush(""), session)
+	r.watchers = make(map[string]*proto.RoomUser)
+}
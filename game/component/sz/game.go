@@ -12,11 +12,13 @@ import (
 )
 
 type GameFrame struct {
-	r          base.RoomFrame
-	gameRule   proto.GameRule
-	gameData   *GameData
-	logic      *Logic
-	gameResult *GameResult
+	r            base.RoomFrame
+	gameRule     proto.GameRule
+	gameData     *GameData
+	logic        *Logic
+	gameResult   *GameResult
+	pendingReady bool //结算之后等待自动准备
+	pendingPour  bool //弃牌之后等待结束下分
 }
 
 func (g *GameFrame) GameMessageHandle(user *proto.RoomUser, session *remote.Session, msg []byte) {
@@ -32,6 +34,8 @@ func (g *GameFrame) GameMessageHandle(user *proto.RoomUser, session *remote.Sess
 		g.onGameCompare(user, session, req.Data.ChairID)
 	} else if req.Type == GameAbandonNotify {
 		g.onGameAbandon(user, session)
+	} else if req.Type == GameChatNotify {
+		g.r.UserChat(session, proto.ChatType(req.Data.Type), req.Data.Msg, req.Data.RecipientID)
 	}
 }
 
@@ -50,6 +54,7 @@ func initGameData(rule proto.GameRule) *GameData {
 		GameType:   GameType(rule.GameFrameType),
 		BaseScore:  rule.BaseScore,
 		ChairCount: rule.MaxPlayerCount,
+		MaxBureau:  rule.Bureau,
 	}
 	g.PourScores = make([][]int, g.ChairCount)
 	g.HandCards = make([][]int, g.ChairCount)
@@ -75,7 +80,8 @@ func (g *GameFrame) GetGameData(session *remote.Session) any {
 			gameData.HandCards[i] = nil
 		}
 	}
-	if g.gameData.LookCards[user.ChairID] == 1 {
+	//观战的用户 看不到任何人的牌
+	if user != nil && g.gameData.LookCards[user.ChairID] == 1 {
 		//已经看牌了
 		gameData.HandCards[user.ChairID] = g.gameData.HandCards[user.ChairID]
 	}
@@ -83,45 +89,59 @@ func (g *GameFrame) GetGameData(session *remote.Session) any {
 }
 
 func (g *GameFrame) ServerMessagePush(users []string, data any, session *remote.Session) {
-	session.Push(users, data, "ServerMessagePush")
+	g.r.ServerMessagePush(users, data, session)
 }
 
 func (g *GameFrame) StartGame(session *remote.Session, user *proto.RoomUser) {
-	//1.用户信息变更推送（金币变化） {"gold": 9958, "pushRouter": 'UpdateUserInfoPush'}
+	//1.金币变化由房间在结算之后推送
 	users := g.getAllUsers()
-	g.ServerMessagePush(users, UpdateUserInfoPushGold(user.UserInfo.Gold), session)
+	receivers := g.getReceivers()
 	//2.庄家推送 {"type":414,"data":{"bankerChairID":0},"pushRouter":"GameMessagePush"}
 	if g.gameData.CurBureau == 0 {
 		//庄家是每次开始游戏 首次进行操作的座次
 		g.gameData.BankerChairID = utils.Rand(len(users))
 	}
 	g.gameData.CurChairID = g.gameData.BankerChairID
-	g.ServerMessagePush(users, GameBankerPushData(g.gameData.BankerChairID), session)
+	g.ServerMessagePush(receivers, GameBankerPushData(g.gameData.BankerChairID), session)
 	//3.局数推送{"type":411,"data":{"curBureau":6},"pushRouter":"GameMessagePush"}
 	g.gameData.CurBureau++
-	g.ServerMessagePush(users, GameBureauPushData(g.gameData.CurBureau), session)
+	g.ServerMessagePush(receivers, GameBureauPushData(g.gameData.CurBureau), session)
 	//4.游戏状态推送 分两步推送 第一步 推送 发牌 牌发完之后 第二步 推送下分 需要用户操作了 推送操作
 	//{"type":401,"data":{"gameStatus":1,"tick":0},"pushRouter":"GameMessagePush"}
 	g.gameData.GameStatus = SendCards
-	g.ServerMessagePush(users, GameStatusPushData(g.gameData.GameStatus, 0), session)
+	g.ServerMessagePush(receivers, GameStatusPushData(g.gameData.GameStatus, 0), session)
 	//5.发牌推送
 	g.sendCards(session)
 	//6.下分推送
 	//先推送下分状态
 	g.gameData.GameStatus = PourScore
-	g.ServerMessagePush(users, GameStatusPushData(g.gameData.GameStatus, 30), session)
+	g.ServerMessagePush(receivers, GameStatusPushData(g.gameData.GameStatus, 30), session)
 	g.gameData.CurScore = g.gameRule.AddScores[0] * g.gameRule.BaseScore
 	for _, v := range g.r.GetUsers() {
 		g.ServerMessagePush([]string{v.UserInfo.Uid}, GamePourScorePushData(v.ChairID, g.gameData.CurScore, g.gameData.CurScore, 1, 0), session)
 	}
 	//7. 轮数推送
 	g.gameData.Round = 1
-	g.ServerMessagePush(users, GameRoundPushData(g.gameData.Round), session)
+	g.ServerMessagePush(receivers, GameRoundPushData(g.gameData.Round), session)
 	//8. 操作推送
 	for _, v := range g.r.GetUsers() {
 		//GameTurnPushData ChairID是做操作的座次号（是哪个用户在做操作）
 		g.ServerMessagePush([]string{v.UserInfo.Uid}, GameTurnPushData(g.gameData.CurChairID, g.gameData.CurScore), session)
 	}
+	g.sendWatchers(GameTurnPushData(g.gameData.CurChairID, g.gameData.CurScore), session)
+}
+
+// getReceivers 公开消息的接收者 玩家和观战的用户
+func (g *GameFrame) getReceivers() []string {
+	return append(g.getAllUsers(), g.r.GetWatchers()...)
+}
+
+func (g *GameFrame) sendWatchers(data any, session *remote.Session) {
+	watchers := g.r.GetWatchers()
+	if len(watchers) == 0 {
+		return
+	}
+	g.ServerMessagePush(watchers, data, session)
 }
 
 func (g *GameFrame) getAllUsers() []string {
@@ -148,12 +168,12 @@ func (g *GameFrame) sendCards(session *remote.Session) {
 			hands[i] = []int{0, 0, 0}
 		}
 	}
-	g.ServerMessagePush(g.getAllUsers(), GameSendCardsPushData(hands), session)
+	g.ServerMessagePush(g.getReceivers(), GameSendCardsPushData(hands), session)
 }
 
 func (g *GameFrame) IsPlayingChairID(chairID int) bool {
 	for _, v := range g.r.GetUsers() {
-		if v.ChairID == chairID && v.UserStatus == proto.Playing {
+		if v.ChairID == chairID && v.UserStatus&proto.Playing != 0 {
 			return true
 		}
 	}
@@ -185,6 +205,7 @@ func (g *GameFrame) onGameLook(user *proto.RoomUser, session *remote.Session, cu
 
 		}
 	}
+	g.sendWatchers(GameLookPushData(g.gameData.CurChairID, nil, cuopai), session)
 }
 
 func (g *GameFrame) onGamePourScore(user *proto.RoomUser, session *remote.Session, score int, t int) {
@@ -222,7 +243,7 @@ func (g *GameFrame) onGamePourScore(user *proto.RoomUser, session *remote.Sessio
 	for _, sc := range g.gameData.PourScores[user.ChairID] {
 		chairCount += sc
 	}
-	g.ServerMessagePush(g.getAllUsers(), GamePourScorePushData(user.ChairID, score, chairCount, scores, t), session)
+	g.ServerMessagePush(g.getReceivers(), GamePourScorePushData(user.ChairID, score, chairCount, scores, t), session)
 	//2. 结束下分 座次移动到下一位 推送轮次 推送游戏状态 推送操作的座次
 	g.endPourScore(session)
 }
@@ -230,7 +251,7 @@ func (g *GameFrame) onGamePourScore(user *proto.RoomUser, session *remote.Sessio
 func (g *GameFrame) endPourScore(session *remote.Session) {
 	//1. 推送轮次 TODO 轮数大于规则的限制 结束游戏 进行结算
 	round := g.getCurRound()
-	g.ServerMessagePush(g.getAllUsers(), GameRoundPushData(round), session)
+	g.ServerMessagePush(g.getReceivers(), GameRoundPushData(round), session)
 	//判断当前的玩家 没有lose的 只剩下一个的时候
 	gamerCount := 0
 	for i := 0; i < g.gameData.ChairCount; i++ {
@@ -251,9 +272,9 @@ func (g *GameFrame) endPourScore(session *remote.Session) {
 		}
 		//推送游戏状态
 		g.gameData.GameStatus = PourScore
-		g.ServerMessagePush(g.getAllUsers(), GameStatusPushData(g.gameData.GameStatus, 30), session)
+		g.ServerMessagePush(g.getReceivers(), GameStatusPushData(g.gameData.GameStatus, 30), session)
 		//该谁操作了
-		g.ServerMessagePush(g.getAllUsers(), GameTurnPushData(g.gameData.CurChairID, g.gameData.CurScore), session)
+		g.ServerMessagePush(g.getReceivers(), GameTurnPushData(g.gameData.CurChairID, g.gameData.CurScore), session)
 
 	}
 }
@@ -284,11 +305,11 @@ func (g *GameFrame) onGameCompare(user *proto.RoomUser, session *remote.Session,
 	winChairID := -1
 	loseChairID := -1
 	if result > 0 {
-		g.ServerMessagePush(g.getAllUsers(), GameComparePushData(fromChairID, toChairID, fromChairID, toChairID), session)
+		g.ServerMessagePush(g.getReceivers(), GameComparePushData(fromChairID, toChairID, fromChairID, toChairID), session)
 		winChairID = fromChairID
 		loseChairID = toChairID
 	} else if result < 0 {
-		g.ServerMessagePush(g.getAllUsers(), GameComparePushData(fromChairID, toChairID, toChairID, fromChairID), session)
+		g.ServerMessagePush(g.getReceivers(), GameComparePushData(fromChairID, toChairID, toChairID, fromChairID), session)
 		winChairID = toChairID
 		loseChairID = fromChairID
 	}
@@ -307,7 +328,7 @@ func (g *GameFrame) onGameCompare(user *proto.RoomUser, session *remote.Session,
 func (g *GameFrame) startResult(session *remote.Session) {
 	//推送 游戏结果状态
 	g.gameData.GameStatus = Result
-	g.ServerMessagePush(g.getAllUsers(), GameStatusPushData(g.gameData.GameStatus, 0), session)
+	g.ServerMessagePush(g.getReceivers(), GameStatusPushData(g.gameData.GameStatus, 0), session)
 	if g.gameResult == nil {
 		g.gameResult = new(GameResult)
 	}
@@ -315,48 +336,56 @@ func (g *GameFrame) startResult(session *remote.Session) {
 	g.gameResult.HandCards = g.gameData.HandCards
 	g.gameResult.CurScores = g.gameData.CurScores
 	g.gameResult.Losers = g.gameData.Loser
-	winScores := make([]int, g.gameData.ChairCount)
-	for i := range winScores {
-		if g.gameData.PourScores[i] != nil {
-			scores := 0
-			for _, v := range g.gameData.PourScores[i] {
-				scores += v
-			}
-			winScores[i] = -scores
-			for win := range g.gameData.Winner {
-				winScores[win] += scores / len(g.gameData.Winner)
-			}
-		}
-	}
-	g.gameResult.WinScores = winScores
-	g.ServerMessagePush(g.getAllUsers(), GameResultPushData(g.gameResult), session)
+	g.gameResult.WinScores = g.winScores()
+	g.ServerMessagePush(g.getReceivers(), GameResultPushData(g.gameResult), session)
 	//结算完成 重置游戏 开始下一把
 	g.resetGame(session)
 	g.gameEnd(session)
 }
 
-func (gf *GameFrame) resetGame(session *remote.Session) {
-	g := &GameData{
-		GameType:   GameType(gf.gameRule.GameFrameType),
-		BaseScore:  gf.gameRule.BaseScore,
-		ChairCount: gf.gameRule.MaxPlayerCount,
+// winScores 每个座次的输赢 没有输的玩家平分所有人下的分，除不尽的余数给庄家之后的第一个赢家
+func (g *GameFrame) winScores() []int {
+	winScores := make([]int, g.gameData.ChairCount)
+	winners := make([]int, 0)
+	pot := 0
+	for i := 0; i < g.gameData.ChairCount; i++ {
+		for _, v := range g.gameData.PourScores[i] {
+			pot += v
+			winScores[i] -= v
+		}
+		if g.IsPlayingChairID(i) && !utils.Contains(g.gameData.Loser, i) {
+			winners = append(winners, i)
+		}
 	}
-	g.PourScores = make([][]int, g.ChairCount)
-	g.HandCards = make([][]int, g.ChairCount)
-	g.LookCards = make([]int, g.ChairCount)
-	g.CurScores = make([]int, g.ChairCount)
-	g.UserStatusArray = make([]UserStatus, g.ChairCount)
-	g.UserTrustArray = []bool{false, false, false, false, false, false, false, false, false, false}
-	g.Loser = make([]int, 0)
-	g.Winner = make([]int, 0)
+	if len(winners) == 0 {
+		return make([]int, g.gameData.ChairCount)
+	}
+	for _, i := range winners {
+		winScores[i] += pot / len(winners)
+	}
+	rest := pot % len(winners)
+	for i := 1; i <= g.gameData.ChairCount && rest > 0; i++ {
+		chairID := (g.gameData.BankerChairID + i) % g.gameData.ChairCount
+		if utils.Contains(winners, chairID) {
+			winScores[chairID] += rest
+			rest = 0
+		}
+	}
+	return winScores
+}
+
+// resetGame 重置这一局的数据 局数保留
+func (gf *GameFrame) resetGame(session *remote.Session) {
+	g := initGameData(gf.gameRule)
+	g.CurBureau = gf.gameData.CurBureau
 	g.GameStatus = GameStatus(None)
 	gf.gameData = g
 	gf.SendGameStatus(g.GameStatus, 0, session)
-	gf.r.EndGame(session)
+	gf.r.EndGame(session, gf.gameResult.WinScores)
 }
 
 func (g *GameFrame) SendGameStatus(status GameStatus, tick int, session *remote.Session) {
-	g.ServerMessagePush(g.getAllUsers(), GameStatusPushData(status, tick), session)
+	g.ServerMessagePush(g.getReceivers(), GameStatusPushData(status, tick), session)
 }
 
 func (g *GameFrame) gameEnd(session *remote.Session) {
@@ -367,13 +396,19 @@ func (g *GameFrame) gameEnd(session *remote.Session) {
 			g.gameData.CurChairID = g.gameData.BankerChairID
 		}
 	}
-	time.AfterFunc(5*time.Second, func() {
-		for _, v := range g.r.GetUsers() {
-			g.r.UserReady(v.UserInfo.Uid, session)
-		}
+	g.pendingReady = true
+	g.r.AfterFunc(5*time.Second, func() {
+		g.autoReady(session)
 	})
 }
 
+func (g *GameFrame) autoReady(session *remote.Session) {
+	g.pendingReady = false
+	for _, v := range g.r.GetUsers() {
+		g.r.UserReady(v.UserInfo.Uid, session)
+	}
+}
+
 func (g *GameFrame) onGameAbandon(user *proto.RoomUser, session *remote.Session) {
 	if !g.IsPlayingChairID(user.ChairID) {
 		return
@@ -391,11 +426,13 @@ func (g *GameFrame) onGameAbandon(user *proto.RoomUser, session *remote.Session)
 	//推送弃牌
 	g.send(GameAbandonPushData(user.ChairID, g.gameData.UserStatusArray[user.ChairID]), session)
 
-	time.AfterFunc(time.Second, func() {
+	g.pendingPour = true
+	g.r.AfterFunc(time.Second, func() {
+		g.pendingPour = false
 		g.endPourScore(session)
 	})
 }
 
 func (g *GameFrame) send(data any, session *remote.Session) {
-	g.ServerMessagePush(g.getAllUsers(), data, session)
+	g.ServerMessagePush(g.getReceivers(), data, session)
 }
@@ -0,0 +1,61 @@
+package sz
+
+import (
+	"game/component/base"
+	"game/component/proto"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// fakeRoom 只实现计算输赢用到的GetUsers
+type fakeRoom struct {
+	base.RoomFrame
+	users map[string]*proto.RoomUser
+}
+
+func (r *fakeRoom) GetUsers() map[string]*proto.RoomUser {
+	return r.users
+}
+
+func newTestFrame(chairCount int) *GameFrame {
+	r := &fakeRoom{users: make(map[string]*proto.RoomUser)}
+	for i := 0; i < chairCount; i++ {
+		uid := strconv.Itoa(i)
+		r.users[uid] = &proto.RoomUser{UserInfo: proto.UserInfo{Uid: uid}, ChairID: i, UserStatus: proto.Playing}
+	}
+	return NewGameFrame(proto.GameRule{MaxPlayerCount: chairCount}, r)
+}
+
+func TestWinScores(t *testing.T) {
+	cases := []struct {
+		name   string
+		pours  [][]int
+		losers []int
+		banker int
+		want   []int
+	}{
+		{"one winner", [][]int{{2, 4}, {2}, {2, 2}}, []int{1, 2}, 0, []int{6, -2, -4}},
+		{"split evenly", [][]int{{2, 4}, {2}, {2, 2}}, []int{1}, 0, []int{0, -2, 2}},
+		{"remainder to first winner after banker", [][]int{{3}, {2}, {2}}, []int{1}, 0, []int{0, -2, 2}},
+		{"remainder wraps around banker", [][]int{{3}, {2}, {2}}, []int{1}, 2, []int{1, -2, 1}},
+		{"no winner", [][]int{{2}, {2}, {2}}, []int{0, 1, 2}, 0, []int{0, 0, 0}},
+	}
+	for _, c := range cases {
+		g := newTestFrame(len(c.pours))
+		g.gameData.PourScores = c.pours
+		g.gameData.Loser = c.losers
+		g.gameData.BankerChairID = c.banker
+		got := g.winScores()
+		if !reflect.DeepEqual(got, c.want) {
+			t.Fatalf("%s: winScores=%v, want %v", c.name, got, c.want)
+		}
+		sum := 0
+		for _, v := range got {
+			sum += v
+		}
+		if sum != 0 {
+			t.Fatalf("%s: winScores=%v is not zero-sum", c.name, got)
+		}
+	}
+}
@@ -9,6 +9,9 @@ type MessageData struct {
 	Score   int  `json:"score"`
 	Type    int  `json:"type"` //1 跟注 2 加注
 	ChairID int  `json:"chairID"`
+	//聊天 type为聊天类型
+	Msg         string `json:"msg"`
+	RecipientID int    `json:"recipientID"`
 }
 type GameStatus int
 
@@ -136,14 +139,6 @@ const (
 	GameReviewPush      = 416
 )
 
-// UpdateUserInfoPushGold  {"gold": 9958, "pushRouter": 'UpdateUserInfoPush'}
-func UpdateUserInfoPushGold(gold int64) any {
-	return map[string]any{
-		"gold":       gold,
-		"pushRouter": "UpdateUserInfoPush",
-	}
-}
-
 //{"type":414,"data":{"bankerChairID":0},"pushRouter":"GameMessagePush"}
 
 func GameBankerPushData(bankerChairID int) any {
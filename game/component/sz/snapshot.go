@@ -0,0 +1,59 @@
+package sz
+
+import (
+	"encoding/json"
+	"fmt"
+	"framework/remote"
+	"time"
+)
+
+// snapshot 持久化的游戏数据 包括牌堆和等待执行的定时操作
+type snapshot struct {
+	GameData     *GameData   `json:"gameData"`
+	Cards        []int       `json:"cards"`
+	GameResult   *GameResult `json:"gameResult"`
+	PendingReady bool        `json:"pendingReady"`
+	PendingPour  bool        `json:"pendingPour"`
+}
+
+func (g *GameFrame) Snapshot() ([]byte, error) {
+	g.logic.RLock()
+	defer g.logic.RUnlock()
+	return json.Marshal(&snapshot{
+		GameData:     g.gameData,
+		Cards:        g.logic.cards,
+		GameResult:   g.gameResult,
+		PendingReady: g.pendingReady,
+		PendingPour:  g.pendingPour,
+	})
+}
+
+func (g *GameFrame) Restore(data []byte, session *remote.Session) error {
+	var s snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s.GameData == nil || s.GameData.ChairCount != g.gameData.ChairCount {
+		return fmt.Errorf("sz snapshot chair count mismatch")
+	}
+	g.gameData = s.GameData
+	g.logic.Lock()
+	g.logic.cards = s.Cards
+	g.logic.Unlock()
+	g.gameResult = s.GameResult
+	//定时任务没有保存 重新启动
+	if s.PendingPour {
+		g.pendingPour = true
+		g.r.AfterFunc(time.Second, func() {
+			g.pendingPour = false
+			g.endPourScore(session)
+		})
+	}
+	if s.PendingReady {
+		g.pendingReady = true
+		g.r.AfterFunc(5*time.Second, func() {
+			g.autoReady(session)
+		})
+	}
+	return nil
+}
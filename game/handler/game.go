@@ -3,11 +3,15 @@ package handler
 import (
 	"common"
 	"common/biz"
+	"context"
 	"core/repo"
 	"core/service"
 	"encoding/json"
 	"fmt"
+	"framework/msError"
 	"framework/remote"
+	"game/component/proto"
+	"game/component/room"
 	"game/logic"
 	"game/models/request"
 )
@@ -32,6 +36,9 @@ func (h *GameHandler) RoomMessageNotify(session *remote.Session, msg []byte) any
 	}
 	rm := h.um.GetRoomById(fmt.Sprintf("%v", roomId))
 	if rm == nil {
+		if req.Type == proto.UserReconnectNotify {
+			h.clearDeadRoom(session, fmt.Sprintf("%v", roomId))
+		}
 		return common.Failed(biz.NotInRoom)
 	}
 	rm.RoomMessageHandle(session, req)
@@ -55,6 +62,79 @@ func (h *GameHandler) GameMessageNotify(session *remote.Session, msg []byte) any
 	return nil
 }
 
+// LeaveWatch 离开观战
+func (h *GameHandler) LeaveWatch(session *remote.Session, msg []byte) any {
+	rm, bizErr := h.sessionRoom(session)
+	if bizErr != nil {
+		return common.Failed(bizErr)
+	}
+	if bizErr := rm.LeaveWatch(session); bizErr != nil {
+		return common.Failed(bizErr)
+	}
+	return common.Successed(nil)
+}
+
+// SitDown 观战的用户坐下
+func (h *GameHandler) SitDown(session *remote.Session, msg []byte) any {
+	rm, bizErr := h.sessionRoom(session)
+	if bizErr != nil {
+		return common.Failed(bizErr)
+	}
+	//坐下时按最新的金币校验房费
+	userData, bizErr := h.userService.FindUserByUid(context.TODO(), session.GetUid())
+	if bizErr != nil {
+		return common.Failed(bizErr)
+	}
+	if userData == nil {
+		return common.Failed(biz.InvalidUsers)
+	}
+	if bizErr := rm.SitDown(session, userData); bizErr != nil {
+		return common.Failed(bizErr)
+	}
+	return common.Successed(nil)
+}
+
+func (h *GameHandler) sessionRoom(session *remote.Session) (*room.Room, *msError.Error) {
+	if len(session.GetUid()) <= 0 {
+		return nil, biz.InvalidUsers
+	}
+	roomId, ok := session.Get("roomId")
+	if !ok {
+		return nil, biz.NotInRoom
+	}
+	rm := h.um.GetRoomById(fmt.Sprintf("%v", roomId))
+	if rm == nil {
+		return nil, biz.NotInRoom
+	}
+	return rm, nil
+}
+
+// clearDeadRoom 断线重连时房间已经不存在(打完或者节点重启后没有恢复) 清除记录的房间号和路由
+func (h *GameHandler) clearDeadRoom(session *remote.Session, roomId string) {
+	h.userService.ClearUserRoom(context.TODO(), session.GetUid(), roomId)
+	session.Put("roomId", "")
+	session.Put("gameType", 0)
+	session.UnbindServer("game")
+	session.Push([]string{session.GetUid()}, proto.UpdateUserInfoPush(""), "ServerMessagePush")
+}
+
+// SessionClose connector通知客户端连接断开 房间中标记为掉线
+func (h *GameHandler) SessionClose(session *remote.Session, msg []byte) any {
+	if len(session.GetUid()) <= 0 {
+		return nil
+	}
+	roomId, ok := session.Get("roomId")
+	if !ok {
+		return nil
+	}
+	rm := h.um.GetRoomById(fmt.Sprintf("%v", roomId))
+	if rm == nil {
+		return nil
+	}
+	rm.UserOffline(session)
+	return nil
+}
+
 func NewGameHandler(r *repo.Manager, um *logic.UnionManager) *GameHandler {
 	return &GameHandler{
 		um:          um,
@@ -70,6 +70,30 @@ func (h *UnionHandler) JoinRoom(session *remote.Session, msg []byte) any {
 	}
 	return common.Successed(nil)
 }
+
+// WatchRoom 观战
+func (h *UnionHandler) WatchRoom(session *remote.Session, msg []byte) any {
+	uid := session.GetUid()
+	if len(uid) <= 0 {
+		return common.Failed(biz.InvalidUsers)
+	}
+	var req request.JoinRoomReq
+	if err := json.Unmarshal(msg, &req); err != nil {
+		return common.Failed(biz.RequestDataError)
+	}
+	userData, err := h.userService.FindUserByUid(context.TODO(), uid)
+	if err != nil {
+		return common.Failed(err)
+	}
+	if userData == nil {
+		return common.Failed(biz.InvalidUsers)
+	}
+	bizErr := h.um.WatchRoom(session, req.RoomID, userData)
+	if bizErr != nil {
+		return common.Failed(bizErr)
+	}
+	return common.Successed(nil)
+}
 func NewUnionHandler(r *repo.Manager, um *logic.UnionManager) *UnionHandler {
 	return &UnionHandler{
 		um:          um,
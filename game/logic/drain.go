@@ -0,0 +1,72 @@
+package logic
+
+import (
+	"common/logs"
+	"context"
+	"game/component/room"
+	"time"
+)
+
+// Drain 不再创建新房间，已有房间打完当前这局之后解散
+func (u *UnionManager) Drain() {
+	if u.draining.CompareAndSwap(false, true) {
+		logs.Info("game server draining,rooms=%d", u.RoomCount())
+	}
+}
+
+func (u *UnionManager) Draining() bool {
+	return u.draining.Load()
+}
+
+func (u *UnionManager) RoomCount() int {
+	return len(u.rooms())
+}
+
+func (u *UnionManager) rooms() []*room.Room {
+	u.RLock()
+	unions := make([]*Union, 0, len(u.unionList))
+	for _, v := range u.unionList {
+		unions = append(unions, v)
+	}
+	u.RUnlock()
+	rooms := make([]*room.Room, 0)
+	for _, v := range unions {
+		v.RLock()
+		for _, r := range v.RoomList {
+			rooms = append(rooms, r)
+		}
+		v.RUnlock()
+	}
+	return rooms
+}
+
+// WaitRooms 等待所有房间解散，ctx结束时返回false
+func (u *UnionManager) WaitRooms(ctx context.Context) bool {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		count := u.RoomCount()
+		if count == 0 {
+			return true
+		}
+		select {
+		case <-ctx.Done():
+			logs.Warn("wait rooms timeout,rooms=%d", count)
+			return false
+		case <-ticker.C:
+		}
+	}
+}
+
+// SuspendRooms 保存剩余房间的快照并停止房间，节点重启之后恢复，返回保存的房间数
+func (u *UnionManager) SuspendRooms() int {
+	saved := 0
+	for _, r := range u.rooms() {
+		if bizErr := r.Suspend(); bizErr != nil {
+			logs.With(logs.KeyRoomId, r.Id).Error("suspend room err:%v", bizErr.Err)
+			continue
+		}
+		saved++
+	}
+	return saved
+}
@@ -0,0 +1,144 @@
+package logic
+
+import (
+	"common/logs"
+	"context"
+	"core/models/entity"
+	"encoding/json"
+	"fmt"
+	"framework/msError"
+	"framework/remote"
+	"game/component/room"
+	"time"
+)
+
+// 房间持久化 实现room.Store，在房间协程中同步调用
+const storeTimeout = 3 * time.Second
+
+func (u *UnionManager) SaveRoom(roomId string, data []byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	u.roomService.SaveSnapshot(ctx, u.serverId, roomId, data)
+}
+
+func (u *UnionManager) DeleteRoom(roomId string) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	u.roomService.DeleteSnapshot(ctx, u.serverId, roomId)
+}
+
+func (u *UnionManager) UpdateUserRoom(uid string, roomId string, frontendId string) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	//记录房间所在的节点 断线重连时connector据此恢复路由
+	serverId := ""
+	if len(roomId) > 0 {
+		serverId = u.serverId
+	}
+	u.userService.UpdateUserRoom(ctx, uid, roomId, frontendId, serverId)
+}
+
+func (u *UnionManager) SaveGameRecord(record *entity.GameRecord) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	u.gameRecordService.Save(ctx, record)
+}
+
+func (u *UnionManager) ChargeRoomFee(uid string, roomId string, instanceId string, fee int64) (int64, *msError.Error) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	return u.roomFeeService.Charge(ctx, uid, roomId, instanceId, fee)
+}
+
+func (u *UnionManager) RefundRoomFee(uid string, roomId string, instanceId string, fee int64) (int64, *msError.Error) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	return u.roomFeeService.Refund(ctx, uid, roomId, instanceId, fee)
+}
+
+func (u *UnionManager) FindVoice(id string) *entity.VoiceMeta {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	meta, _ := u.voiceService.FindMeta(ctx, id)
+	return meta
+}
+
+// Settle 幂等key为房间的instanceId、局数和uid 同一局不会重复结算
+func (u *UnionManager) Settle(roomId string, instanceId string, hand int, unionId int64, deltas map[string]int64) (map[string]int64, *msError.Error) {
+	return u.changeBalances(fmt.Sprintf("settle:%s:%d", instanceId, hand), roomId, unionId, deltas, entity.ReasonSettlement)
+}
+
+// VoidSettle 幂等key为房间的instanceId和uid 一个房间只退回一次
+func (u *UnionManager) VoidSettle(roomId string, instanceId string, unionId int64, deltas map[string]int64) (map[string]int64, *msError.Error) {
+	return u.changeBalances(fmt.Sprintf("void:%s", instanceId), roomId, unionId, deltas, entity.ReasonSettlementVoid)
+}
+
+// changeBalances 在一个事务中修改房间玩家的金币或者联盟积分 返回修改之后的余额
+func (u *UnionManager) changeBalances(keyPrefix string, roomId string, unionId int64, deltas map[string]int64, reason string) (map[string]int64, *msError.Error) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	currency, ledgerUnionId := entity.CurrencyGold, int64(0)
+	if unionId != room.PersonalUnionId {
+		currency, ledgerUnionId = entity.CurrencyUnionScore, unionId
+	}
+	entries := make([]*entity.Ledger, 0, len(deltas))
+	for uid, delta := range deltas {
+		entries = append(entries, &entity.Ledger{
+			IdempotencyKey: fmt.Sprintf("%s:%s", keyPrefix, uid),
+			Uid:            uid,
+			Currency:       currency,
+			UnionID:        ledgerUnionId,
+			Delta:          delta,
+			Reason:         reason,
+			RefID:          roomId,
+		})
+	}
+	if err := u.ledgerService.Change(ctx, entries, false); err != nil {
+		return nil, err
+	}
+	balances := make(map[string]int64, len(entries))
+	for _, e := range entries {
+		balances[e.Uid] = e.Balance
+	}
+	return balances, nil
+}
+
+// RestoreRooms 节点启动时恢复上次保存的房间，恢复失败的房间删除快照并清除用户的房间号
+// newSession创建向指定connector推送消息的session
+func (u *UnionManager) RestoreRooms(ctx context.Context, newSession func(connectorId string) *remote.Session) int {
+	snapshots, bizErr := u.roomService.FindSnapshots(ctx, u.serverId)
+	if bizErr != nil {
+		return 0
+	}
+	restored := 0
+	for roomId, data := range snapshots {
+		log := logs.With(logs.KeyRoomId, roomId)
+		var s room.Snapshot
+		if err := json.Unmarshal([]byte(data), &s); err != nil {
+			log.Error("parse room snapshot err:%v", err)
+			u.discardSnapshot(ctx, roomId, nil)
+			continue
+		}
+		union := u.GetUnion(s.UnionId)
+		r, err := room.RestoreRoom([]byte(data), union, u.scheduler, u, newSession)
+		if err != nil {
+			log.Error("restore room err:%v", err)
+			u.discardSnapshot(ctx, roomId, &s)
+			continue
+		}
+		union.Lock()
+		union.RoomList[r.Id] = r
+		union.Unlock()
+		restored++
+	}
+	return restored
+}
+
+func (u *UnionManager) discardSnapshot(ctx context.Context, roomId string, s *room.Snapshot) {
+	if s != nil {
+		for uid, user := range s.Users {
+			u.userService.UpdateUserRoom(ctx, uid, "", user.UserInfo.FrontendId, "")
+		}
+	}
+	u.roomService.DeleteSnapshot(ctx, u.serverId, roomId)
+}
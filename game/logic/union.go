@@ -1,6 +1,7 @@
 package logic
 
 import (
+	"common/biz"
 	"core/models/entity"
 	"core/service"
 	"framework/msError"
@@ -18,18 +19,39 @@ type Union struct {
 }
 
 func (u *Union) CreateRoom(service *service.UserService, session *remote.Session, req request.CreateRoomReq, userData *entity.User) *msError.Error {
+	if u.m.Draining() {
+		return biz.ServerMaintenance
+	}
 	//1. 需要创建一个房间 生成一个房间号
 	roomId := u.m.CreateRoomId()
-	newRoom := room.NewRoom(roomId, req.UnionID, req.GameRule, u)
+	newRoom := room.NewRoom(roomId, req.UnionID, req.GameRule, u, u.m.scheduler, u.m)
+	u.Lock()
 	u.RoomList[roomId] = newRoom
-	return newRoom.UserEntryRoom(session, userData)
+	u.Unlock()
+	if err := newRoom.UserEntryRoom(session, userData); err != nil {
+		//房费不足等 房主没有进入房间
+		newRoom.Abandon()
+		return err
+	}
+	return nil
 }
 
+func (u *Union) GetRoom(roomId string) *room.Room {
+	u.RLock()
+	defer u.RUnlock()
+	return u.RoomList[roomId]
+}
+
+// DismissRoom 在房间协程中调用
 func (u *Union) DismissRoom(roomId string) {
 	u.Lock()
 	defer u.Unlock()
 	delete(u.RoomList, roomId)
 }
+func (u *Union) Draining() bool {
+	return u.m.Draining()
+}
+
 func NewUnion(m *UnionManager) *Union {
 	return &Union{
 		RoomList: make(map[string]*room.Room),
@@ -3,29 +3,55 @@ package logic
 import (
 	"common/biz"
 	"core/models/entity"
+	"core/repo"
+	"core/service"
 	"fmt"
+	"framework/game"
 	"framework/msError"
 	"framework/remote"
 	"game/component/room"
 	"math/rand"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type UnionManager struct {
 	sync.RWMutex
-	unionList map[int64]*Union
+	serverId          string
+	unionList         map[int64]*Union
+	scheduler         *room.Scheduler
+	roomService       *service.RoomService
+	userService       *service.UserService
+	gameRecordService *service.GameRecordService
+	roomFeeService    *service.RoomFeeService
+	ledgerService     *service.LedgerService
+	voiceService      *service.VoiceService
+	draining          atomic.Bool
 }
 
-func NewUnionManager() *UnionManager {
+// NewUnionManager servers.json中maxRunRoutineNum为同时执行任务的房间数量上限
+func NewUnionManager(serverId string, r *repo.Manager) *UnionManager {
+	maxRunRoutineNum := 0
+	if serverConf := game.Conf.GetServer(serverId); serverConf != nil {
+		maxRunRoutineNum = serverConf.MaxRunRoutineNum
+	}
 	return &UnionManager{
-		unionList: make(map[int64]*Union),
+		serverId:          serverId,
+		unionList:         make(map[int64]*Union),
+		scheduler:         room.NewScheduler(maxRunRoutineNum),
+		roomService:       service.NewRoomService(r),
+		userService:       service.NewUserService(r),
+		gameRecordService: service.NewGameRecordService(r),
+		roomFeeService:    service.NewRoomFeeService(r),
+		ledgerService:     service.NewLedgerService(r),
+		voiceService:      service.NewVoiceService(r),
 	}
 }
 
 func (u *UnionManager) GetUnion(unionId int64) *Union {
 	u.Lock()
-	u.Unlock()
+	defer u.Unlock()
 	union, ok := u.unionList[unionId]
 	if ok {
 		return union
@@ -38,11 +64,8 @@ func (u *UnionManager) GetUnion(unionId int64) *Union {
 func (u *UnionManager) CreateRoomId() string {
 	//随机数的方式去创建
 	roomId := u.genRoomId()
-	for _, v := range u.unionList {
-		_, ok := v.RoomList[roomId]
-		if ok {
-			return u.CreateRoomId()
-		}
+	if u.GetRoomById(roomId) != nil {
+		return u.CreateRoomId()
 	}
 	return roomId
 }
@@ -58,9 +81,11 @@ func (u *UnionManager) genRoomId() string {
 }
 
 func (u *UnionManager) GetRoomById(roomId string) *room.Room {
+	u.RLock()
+	defer u.RUnlock()
 	for _, v := range u.unionList {
-		r, ok := v.RoomList[roomId]
-		if ok {
+		r := v.GetRoom(roomId)
+		if r != nil {
 			return r
 		}
 	}
@@ -68,11 +93,27 @@ func (u *UnionManager) GetRoomById(roomId string) *room.Room {
 }
 
 func (u *UnionManager) JoinRoom(session *remote.Session, roomId string, data *entity.User) *msError.Error {
-	for _, v := range u.unionList {
-		r, ok := v.RoomList[roomId]
-		if ok {
-			return r.JoinRoom(session, data)
+	r := u.GetRoomById(roomId)
+	if r == nil {
+		return biz.RoomNotExist
+	}
+	return r.JoinRoom(session, data)
+}
+
+func (u *UnionManager) WatchRoom(session *remote.Session, roomId string, data *entity.User) *msError.Error {
+	r := u.GetRoomById(roomId)
+	if r == nil {
+		return biz.RoomNotExist
+	}
+	if len(data.RoomID) > 0 && data.RoomID != roomId {
+		//坐在其他房间中 需要先离开
+		return biz.AlreadyInOtherRoom
+	}
+	if v, ok := session.Get("roomId"); ok {
+		//正在观战其他房间 先离开
+		if old := u.GetRoomById(fmt.Sprintf("%v", v)); old != nil && old != r {
+			old.LeaveWatch(session)
 		}
 	}
-	return biz.RoomNotExist
+	return r.WatchRoom(session, data)
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"common/cmd"
 	"common/config"
 	"common/metrics"
 	"context"
@@ -17,6 +18,7 @@ var rootCmd = &cobra.Command{
 	Short: "游戏逻辑相关处理",
 	Long:  `game游戏逻辑相关处理 `,
 	Run: func(cmd *cobra.Command, args []string) {
+		run()
 	},
 	PostRun: func(cmd *cobra.Command, args []string) {
 	},
@@ -34,18 +36,22 @@ func init() {
 	rootCmd.Flags().StringVar(&gameConfigDir, "gameDir", "../config", "game1 config dir")
 	rootCmd.Flags().StringVar(&serverId, "serverId", "", "app server id， required")
 	_ = rootCmd.MarkFlagRequired("serverId")
+	rootCmd.AddCommand(cmd.NewConfigCommand())
 }
 func main() {
 	if err := rootCmd.Execute(); err != nil {
 		log.Println(err)
 		os.Exit(1)
 	}
-	config.InitConfig(configFile)
+}
+
+func run() {
+	config.InitConfig(configFile, serverId)
 
 	game.InitConfig(gameConfigDir)
 
 	go func() {
-		err := metrics.Serve(fmt.Sprintf("0.0.0.0:%d", config.Conf.MetricPort))
+		err := metrics.Serve(fmt.Sprintf("0.0.0.0:%d", config.Conf().MetricPort))
 		if err != nil {
 			panic(err)
 		}
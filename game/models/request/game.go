@@ -10,4 +10,10 @@ type RoomMessageReq struct {
 type RoomMessageData struct {
 	IsExit  bool `json:"isExit"`
 	IsReady bool `json:"isReady"`
+	ChairID int  `json:"chairID"` //换座的目标座位 回复换座请求时为发起人的座位
+	IsAgree bool `json:"isAgree"` //是否同意交换座位
+	//聊天
+	Type        proto.ChatType `json:"type"`
+	Msg         string         `json:"msg"`
+	RecipientID int            `json:"recipientID"`
 }
@@ -3,20 +3,24 @@ package route
 import (
 	"core/repo"
 	"framework/node"
+	"framework/remote"
 	"game/handler"
 	"game/logic"
 )
 
-func RegisterHandler(r *repo.Manager) node.LogicHandler {
+func RegisterHandler(r *repo.Manager, um *logic.UnionManager) node.LogicHandler {
 
 	handles := make(node.LogicHandler)
-	um := logic.NewUnionManager()
 	unionHandler := handler.NewUnionHandler(r, um)
 	handles["unionHandler.createRoom"] = unionHandler.CreateRoom
 	handles["unionHandler.joinRoom"] = unionHandler.JoinRoom
+	handles["unionHandler.watchRoom"] = unionHandler.WatchRoom
 	gameHandler := handler.NewGameHandler(r, um)
 	handles["gameHandler.roomMessageNotify"] = gameHandler.RoomMessageNotify
 	handles["gameHandler.gameMessageNotify"] = gameHandler.GameMessageNotify
+	handles["gameHandler.leaveWatch"] = gameHandler.LeaveWatch
+	handles["gameHandler.sitDown"] = gameHandler.SitDown
+	handles[remote.CloseRouter] = gameHandler.SessionClose
 	return handles
 
 }
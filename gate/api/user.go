@@ -7,7 +7,6 @@ import (
 	"common/jwts"
 	"common/logs"
 	"common/rpc"
-	"context"
 	"framework/msError"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -29,10 +28,11 @@ func (u *UserHandler) Register(ctx *gin.Context) {
 		return
 	}
 
-	response, err := rpc.UserClient.Register(context.TODO(), &req)
+	log := logs.Ctx(ctx.Request.Context())
+	response, err := rpc.UserClient.Register(ctx.Request.Context(), &req)
 	if err != nil {
 		common.Fail(ctx, msError.ToError(err))
-		logs.Error("rpc register error:", err)
+		log.Error("rpc register error:%v", err)
 		return
 	}
 	uid := response.Uid
@@ -40,23 +40,23 @@ func (u *UserHandler) Register(ctx *gin.Context) {
 		common.Fail(ctx, biz.SqlError)
 		return
 	}
-	logs.Info("uid:%s", uid)
+	log.Info("uid:%s", uid)
 	claims := jwts.CustomClaims{
 		Uid: uid,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 24 * 7)),
 		},
 	}
-	token, err := jwts.GenToken(&claims, config.Conf.Jwt.Secret)
+	token, err := jwts.GenToken(&claims, config.Conf().Jwt.Secret)
 	if err != nil {
-		logs.Error("jwt gen token error:", err)
+		log.Error("jwt gen token error:%v", err)
 		common.Fail(ctx, biz.Fail)
 	}
 	result := map[string]any{
 		"token": token,
 		"serverInfo": map[string]any{
-			"host": config.Conf.Services["connector"].ClientHost,
-			"port": config.Conf.Services["connector"].ClientPort,
+			"host": config.Conf().Services["connector"].ClientHost,
+			"port": config.Conf().Services["connector"].ClientPort,
 		},
 	}
 	common.Success(ctx, result)
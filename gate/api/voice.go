@@ -0,0 +1,108 @@
+package api
+
+import (
+	"common"
+	"common/biz"
+	"common/logs"
+	"core/repo"
+	"core/service"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+type VoiceHandler struct {
+	voiceService *service.VoiceService
+	userService  *service.UserService
+	roomService  *service.RoomService
+}
+
+func NewVoiceHandler(r *repo.Manager) *VoiceHandler {
+	return &VoiceHandler{
+		voiceService: service.NewVoiceService(r),
+		userService:  service.NewUserService(r),
+		roomService:  service.NewRoomService(r),
+	}
+}
+
+// Upload 上传语音 请求体为音频原始数据，query参数roomId和duration(毫秒)
+// 返回的id通过房间聊天(类型为语音)发送给房间内的其他人
+func (h *VoiceHandler) Upload(ctx *gin.Context) {
+	uid := ctx.GetString("uid")
+	roomId := ctx.Query("roomId")
+	duration, err := strconv.Atoi(ctx.Query("duration"))
+	if err != nil || len(roomId) == 0 {
+		common.Fail(ctx, biz.RequestDataError)
+		return
+	}
+	log := logs.Ctx(ctx.Request.Context())
+	user, bizErr := h.userService.FindUserByUid(ctx.Request.Context(), uid)
+	if bizErr != nil {
+		common.Fail(ctx, bizErr)
+		return
+	}
+	if user == nil || user.RoomID != roomId {
+		common.Fail(ctx, biz.NotInRoom)
+		return
+	}
+	enabled, bizErr := h.roomService.VoiceEnabled(ctx.Request.Context(), user.GameServerId, roomId)
+	if bizErr != nil {
+		common.Fail(ctx, bizErr)
+		return
+	}
+	if !enabled {
+		common.Fail(ctx, biz.VoiceDisabled)
+		return
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, service.VoiceMaxSize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			common.Fail(ctx, biz.VoiceTooLarge)
+			return
+		}
+		log.Error("read voice body err:%v", err)
+		common.Fail(ctx, biz.RequestDataError)
+		return
+	}
+	id, bizErr := h.voiceService.Upload(ctx.Request.Context(), uid, roomId, duration, data)
+	if bizErr != nil {
+		common.Fail(ctx, bizErr)
+		return
+	}
+	common.Success(ctx, map[string]any{
+		"id":       id,
+		"duration": duration,
+	})
+}
+
+// Fetch 获取语音 返回音频原始数据，时长在响应头X-Voice-Duration中
+// 只有发送人和语音所在房间的玩家可以获取
+func (h *VoiceHandler) Fetch(ctx *gin.Context) {
+	uid := ctx.GetString("uid")
+	meta, bizErr := h.voiceService.FindMeta(ctx.Request.Context(), ctx.Param("id"))
+	if bizErr != nil {
+		common.Fail(ctx, bizErr)
+		return
+	}
+	if meta.Uid != uid {
+		user, bizErr := h.userService.FindUserByUid(ctx.Request.Context(), uid)
+		if bizErr != nil {
+			common.Fail(ctx, bizErr)
+			return
+		}
+		if user == nil || user.RoomID != meta.RoomID {
+			common.Fail(ctx, biz.NotInRoom)
+			return
+		}
+	}
+	data, meta, bizErr := h.voiceService.Find(ctx.Request.Context(), ctx.Param("id"))
+	if bizErr != nil {
+		common.Fail(ctx, bizErr)
+		return
+	}
+	ctx.Header("X-Voice-Duration", strconv.Itoa(meta.Duration))
+	ctx.Data(http.StatusOK, "application/octet-stream", data)
+}
@@ -4,6 +4,8 @@ import (
 	"common/config"
 	"common/logs"
 	"context"
+	"core/repo"
+	"core/service"
 	"fmt"
 	"gate/router"
 	"os"
@@ -12,19 +14,26 @@ import (
 	"time"
 )
 
+const voiceCleanInterval = 10 * time.Minute
+
 func Run(ctx context.Context) error {
 	//日志
-	logs.InitLog(config.Conf.AppName)
+	logs.InitLog(config.Conf().AppName)
+	//注册登录以及语音都需要mongo和redis 连接失败时进程直接退出
+	manager := repo.New()
+	cleanCtx, cancelClean := context.WithCancel(ctx)
+	go cleanVoice(cleanCtx, service.NewVoiceService(manager))
 	go func() {
 		//gin启动
-		r := router.RegisterRouter()
-		if err := r.Run(fmt.Sprintf(":%d", config.Conf.HttpPort)); err != nil {
+		r := router.RegisterRouter(manager)
+		if err := r.Run(fmt.Sprintf(":%d", config.Conf().HttpPort)); err != nil {
 			logs.Fatal("gate gin run err:%v", err)
 		}
 	}()
 
 	stop := func() {
-
+		cancelClean()
+		manager.Close()
 		time.Sleep(3 * time.Second)
 		logs.Info("stop app finish")
 	}
@@ -54,3 +63,19 @@ func Run(ctx context.Context) error {
 
 	}
 }
+
+// cleanVoice 定时删除过期的语音
+func cleanVoice(ctx context.Context, voiceService *service.VoiceService) {
+	ticker := time.NewTicker(voiceCleanInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			cleanCtx, cancel := context.WithTimeout(ctx, time.Minute)
+			voiceService.DeleteExpired(cleanCtx)
+			cancel()
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package auth
+
+import (
+	"common"
+	"common/biz"
+	"common/config"
+	"common/jwts"
+	"github.com/gin-gonic/gin"
+)
+
+// Token 校验请求头Token中的jwt，通过之后uid写入gin.Context
+func Token() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, err := jwts.ParseToken(c.GetHeader("Token"), config.Conf().Jwt.Secret)
+		if err != nil {
+			common.Fail(c, biz.TokenInfoError)
+			c.Abort()
+			return
+		}
+		c.Set("uid", uid)
+		c.Next()
+	}
+}
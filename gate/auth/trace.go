@@ -0,0 +1,19 @@
+package auth
+
+import (
+	"common/trace"
+	"github.com/gin-gonic/gin"
+)
+
+// Trace 为每个http请求生成traceId(客户端已携带并且合法则沿用)，写入request context，rpc调用时透传给grpc服务
+func Trace() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		traceId := c.GetHeader(trace.MetadataKey)
+		if !trace.Valid(traceId) {
+			traceId = trace.NewId()
+		}
+		c.Request = c.Request.WithContext(trace.WithContext(c.Request.Context(), traceId))
+		c.Header(trace.MetadataKey, traceId)
+		c.Next()
+	}
+}
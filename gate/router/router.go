@@ -3,14 +3,15 @@ package router
 import (
 	"common/config"
 	"common/rpc"
+	"core/repo"
 	"gate/api"
 	"gate/auth"
 	"github.com/gin-gonic/gin"
 	"net/http"
 )
 
-func RegisterRouter() *gin.Engine {
-	if config.Conf.Log.Level == "DEBUG" {
+func RegisterRouter(manager *repo.Manager) *gin.Engine {
+	if config.Conf().Log.Level == "DEBUG" {
 		gin.SetMode(gin.DebugMode)
 
 	} else {
@@ -19,8 +20,13 @@ func RegisterRouter() *gin.Engine {
 	rpc.Init()
 	r := gin.Default()
 	r.Use(auth.Cors())
+	r.Use(auth.Trace())
 	userHandler := api.NewUserHandler()
 	r.POST("/register", userHandler.Register)
+	voiceHandler := api.NewVoiceHandler(manager)
+	voice := r.Group("/voice", auth.Token())
+	voice.POST("", voiceHandler.Upload)
+	voice.GET("/:id", voiceHandler.Fetch)
 	r.GET("/123", func(c *gin.Context) {
 		c.String(http.StatusOK, "Hello World")
 	})
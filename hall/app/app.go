@@ -15,7 +15,7 @@ import (
 
 func Run(ctx context.Context, serverId string) error {
 	//日志
-	logs.InitLog(config.Conf.AppName)
+	logs.InitLog(config.Conf().AppName)
 	exit := func() {}
 	go func() {
 		n := node.Default()
@@ -3,7 +3,6 @@ package handler
 import (
 	"common"
 	"common/biz"
-	"common/logs"
 	"core/repo"
 	"core/service"
 	"encoding/json"
@@ -17,7 +16,7 @@ type UserHandler struct {
 }
 
 func (h *UserHandler) UpdateUserAddress(session *remote.Session, msg []byte) any {
-	logs.Info("updateUserAddress msg:%v", string(msg))
+	session.Log().Debug("updateUserAddress msg:%v", string(msg))
 	var req request.UpdateUserAddressReq
 	if err := json.Unmarshal(msg, &req); err != nil {
 		return common.Failed(biz.RequestDataError)
@@ -4,6 +4,8 @@ import (
 	"common/config"
 	"common/discovery"
 	"common/logs"
+	"common/metrics"
+	"common/trace"
 	"context"
 	"core/repo"
 	"google.golang.org/grpc"
@@ -19,21 +21,21 @@ import (
 
 func Run(ctx context.Context) error {
 	//日志
-	logs.InitLog(config.Conf.AppName)
+	logs.InitLog(config.Conf().AppName)
 
 	//etcd
 	register := discovery.NewRegister()
 	//启用grpc服务端
-	server := grpc.NewServer()
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(trace.UnaryServerInterceptor(), metrics.UnaryServerInterceptor()))
 	//初始化数据库
 	manager := repo.New()
 	go func() {
-		lis, err := net.Listen("tcp", config.Conf.Grpc.Addr)
+		lis, err := net.Listen("tcp", config.Conf().Grpc.Addr)
 		if err != nil {
 			logs.Fatal("grpc监听错误:%v", err)
 		}
 		//注册grpc service
-		err = register.Register(config.Conf.Etcd)
+		err = register.Register(config.Conf().Etcd)
 
 		if err != nil {
 			logs.Fatal("etcd register err:%v", err)
@@ -2,6 +2,7 @@ package service
 
 import (
 	"common/biz"
+	"common/logs"
 	"context"
 	"core/dao"
 	"core/models/entity"
@@ -31,8 +32,9 @@ func NewAccountService(manager *repo.Manager) *AccountService {
 func (a *AccountService) Register(ctx context.Context, req *pb.RegisterParams) (*pb.RegisterResponse, error) {
 	//注册逻辑
 	if req.LoginPlatform == requests.WeiXin {
-		ac, err := a.wxRegister(req)
+		ac, err := a.wxRegister(ctx, req)
 		if err != nil {
+			logs.Ctx(ctx).Error("wx register err:%v,account=%s", err, req.Account)
 			return &pb.RegisterResponse{}, msError.GrpcError(err)
 		}
 		return &pb.RegisterResponse{
@@ -42,7 +44,7 @@ func (a *AccountService) Register(ctx context.Context, req *pb.RegisterParams) (
 
 	return &pb.RegisterResponse{}, nil
 }
-func (a *AccountService) wxRegister(req *pb.RegisterParams) (*entity.Account, *msError.Error) {
+func (a *AccountService) wxRegister(ctx context.Context, req *pb.RegisterParams) (*entity.Account, *msError.Error) {
 	//封装account结构，存入数据库 Mongo 分布式id，objectID
 	ac := &entity.Account{
 		WxAccount:  req.Account,
@@ -53,7 +55,7 @@ func (a *AccountService) wxRegister(req *pb.RegisterParams) (*entity.Account, *m
 		return ac, biz.SqlError
 	}
 	ac.Uid = strconv.FormatInt(uid, 10)
-	err = a.accountDao.SaveAccount(context.TODO(), ac)
+	err = a.accountDao.SaveAccount(ctx, ac)
 	if err != nil {
 		return ac, biz.SqlError
 
@@ -1,25 +1,47 @@
 package main
 
 import (
+	"common/cmd"
 	"common/config"
 	"common/metrics"
 	"context"
-	"flag"
 	"fmt"
+	"github.com/spf13/cobra"
 	"log"
 	"os"
 	"user/app"
 )
 
-var configFile = flag.String("config", "application.yml", "config file")
+var rootCmd = &cobra.Command{
+	Use:   "user",
+	Short: "user 账号grpc服务",
+	Run: func(cmd *cobra.Command, args []string) {
+		run()
+	},
+}
+
+var configFile string
+
+func init() {
+	rootCmd.Flags().StringVar(&configFile, "config", "application.yml", "app config yml file")
+	rootCmd.AddCommand(cmd.NewConfigCommand())
+}
 
 func main() {
+	//之前使用flag包启动 部署脚本中是-config application.yml
+	rootCmd.SetArgs(cmd.NormalizeArgs(os.Args[1:]))
+	if err := rootCmd.Execute(); err != nil {
+		log.Println(err)
+		os.Exit(1)
+	}
+}
+
+func run() {
 	//加载配置
-	flag.Parse()
-	config.InitConfig(*configFile)
+	config.InitConfig(configFile, "")
 	//启动监听
 	go func() {
-		err := metrics.Serve(fmt.Sprintf("0.0.0.0:%d", config.Conf.MetricPort))
+		err := metrics.Serve(fmt.Sprintf("0.0.0.0:%d", config.Conf().MetricPort))
 		if err != nil {
 			panic(err)
 		}